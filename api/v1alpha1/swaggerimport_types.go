@@ -0,0 +1,152 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// APIScope selects which Azure API Management API resource kind is targeted
+// +kubebuilder:validation:Enum=Namespaced;Cluster
+type APIScope string
+
+const (
+	// APIScopeNamespaced targets apis.apimanagement.azure.m.upbound.io
+	APIScopeNamespaced APIScope = "Namespaced"
+	// APIScopeCluster targets apis.apimanagement.azure.upbound.io
+	APIScopeCluster APIScope = "Cluster"
+)
+
+// Condition types and reasons reported on a SwaggerImport
+const (
+	// ConditionReady is true when the last import attempt succeeded
+	ConditionReady = "Ready"
+
+	ReasonImported              = "Imported"
+	ReasonImportFailed          = "ImportFailed"
+	ReasonInvalidTarget         = "InvalidTarget"
+	ReasonValidationFailed      = "ValidationFailed"
+	ReasonPendingApproval       = "PendingApproval"
+	ReasonRolloutInProgress     = "RolloutInProgress"
+	ReasonReferenceNotPermitted = "ReferenceNotPermitted"
+)
+
+// SourceReference identifies the workload serving the swagger document
+type SourceReference struct {
+	// Service is the name of the Service in the namespace of the SwaggerImport that exposes the workload
+	Service string `json:"service"`
+
+	// Port of the Service to fetch the swagger document from. When omitted all Service ports are tried in order.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// APIReference identifies the Azure API Management API resource to import into
+type APIReference struct {
+	// Name of the API resource
+	Name string `json:"name"`
+
+	// Namespace of a namespaced API resource. Defaults to the namespace of the SwaggerImport.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Scope selects between the namespaced and the cluster scoped API resource
+	// +kubebuilder:default=Namespaced
+	// +optional
+	Scope APIScope `json:"scope,omitempty"`
+}
+
+// SwaggerImportSpec defines the desired state of SwaggerImport
+type SwaggerImportSpec struct {
+	// Source is the workload serving the swagger document
+	Source SourceReference `json:"source"`
+
+	// APIRef is the API resource the swagger document is imported into
+	APIRef APIReference `json:"apiRef"`
+
+	// Path of the swagger document on the workload, or a full URL. Supports the {scheme}, {port},
+	// {version}, {service} and {namespace} placeholders. Defaults to the swaggerimporter/path
+	// annotation of the Service, or the first well-known framework location that serves a document.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Version of the swagger document, e.g. v1.0. Defaults to spec.forProvider.version of the API or the version resolved from its name.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Format is the content format passed to Azure API Management. Detected from the fetched document when omitted.
+	// The fetched document is imported inline, so the link formats of API Management are not supported.
+	// +kubebuilder:validation:Enum=openapi;openapi+json;swagger-json;wadl-xml;wsdl
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Interval between imports
+	// +kubebuilder:default="1m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// SwaggerImportStatus defines the observed state of SwaggerImport
+type SwaggerImportStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the time of the last successful import
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// ConsecutiveFailures is the number of failed import attempts since the last successful one.
+	// Failing imports are retried less often the more they fail.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// Conditions describe the state of the last import attempt
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="API",type=string,JSONPath=`.spec.apiRef.name`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Last Sync",type=date,JSONPath=`.status.lastSyncTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SwaggerImport is the Schema for the swaggerimports API
+type SwaggerImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SwaggerImportSpec   `json:"spec,omitempty"`
+	Status SwaggerImportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SwaggerImportList contains a list of SwaggerImport
+type SwaggerImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SwaggerImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SwaggerImport{}, &SwaggerImportList{})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GrantFrom identifies the namespace whose workloads and SwaggerImports may import into the APIs of a grant
+type GrantFrom struct {
+	// Namespace of the workloads or SwaggerImports
+	Namespace string `json:"namespace"`
+}
+
+// GrantTo identifies the APIs in the namespace of a grant that may be imported into
+type GrantTo struct {
+	// Name of the API resource. All APIs in the namespace of the grant when omitted.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// SwaggerImportGrantSpec defines which namespaces may import into APIs in the namespace of the grant
+type SwaggerImportGrantSpec struct {
+	// From are the namespaces allowed to import
+	// +kubebuilder:validation:MinItems=1
+	From []GrantFrom `json:"from"`
+
+	// To are the APIs that may be imported into. All APIs in the namespace of the grant when empty.
+	// +optional
+	To []GrantTo `json:"to,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SwaggerImportGrant allows workloads and SwaggerImports in other namespaces to import swagger documents into
+// the namespaced APIs in its namespace. Without a grant only imports from the same namespace are allowed.
+type SwaggerImportGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SwaggerImportGrantSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SwaggerImportGrantList contains a list of SwaggerImportGrant
+type SwaggerImportGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SwaggerImportGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SwaggerImportGrant{}, &SwaggerImportGrantList{})
+}
@@ -18,19 +18,25 @@ package main
 
 import (
 	"crypto/tls"
+	"errors"
 	"flag"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	importerv1alpha1 "github.com/fortytwoservices/swagger-importer/api/v1alpha1"
 	"github.com/fortytwoservices/swagger-importer/controllers"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -50,6 +56,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(namespacedapimanagement.AddToScheme(scheme))
 	utilruntime.Must(clusterapimanagement.AddToScheme(scheme))
+	utilruntime.Must(importerv1alpha1.AddToScheme(scheme))
 
 	//+kubebuilder:scaffold:scheme
 }
@@ -60,6 +67,28 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var enablePodLabelImport bool
+	var compareIgnoreFields string
+	var holdBreakingChanges bool
+	var fetchTimeout time.Duration
+	var fetchMaxBytes int64
+	var fetchUserAgent string
+	var fetchInsecureSkipVerify bool
+	var maxFailureBackoff time.Duration
+	var fetchFromPodEndpoint bool
+	var consistentRollouts bool
+	var optInLabel string
+	var appLabel string
+	var apiLabel string
+	var podSelector string
+	var apiSelector string
+	var watchNamespaces string
+	var restrictClusterAPIs bool
+	var versionStrategy string
+	var versionPattern string
+	var versionKey string
+	var versionTemplate string
+	var createVersions bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -69,6 +98,64 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&enablePodLabelImport, "enable-pod-label-import", true,
+		"If set, pods labelled swaggerimporter=true are imported into APIs with a matching application label. "+
+			"Disable to only import swagger declared by SwaggerImport resources.")
+	flag.StringVar(&compareIgnoreFields, "compare-ignore-fields", "",
+		"Comma separated dotted field paths, e.g. info.version, ignored when deciding whether an imported document changed.")
+	flag.BoolVar(&holdBreakingChanges, "hold-breaking-changes", false,
+		"If set, documents with breaking changes within the major version of an API are held until the "+
+			"swaggerimporter/approved-hash annotation of the API matches the swaggerimporter/pending-hash annotation.")
+	flag.DurationVar(&fetchTimeout, "fetch-timeout", 10*time.Second,
+		"Timeout of a single swagger document fetch.")
+	flag.Int64Var(&fetchMaxBytes, "fetch-max-bytes", 10<<20,
+		"Maximum size in bytes of a fetched swagger document.")
+	flag.StringVar(&fetchUserAgent, "fetch-user-agent", "swagger-importer",
+		"User agent of swagger document fetches.")
+	flag.BoolVar(&fetchInsecureSkipVerify, "fetch-insecure-skip-verify", false,
+		"If set, TLS certificates of workloads serving swagger over https are not verified.")
+	flag.DurationVar(&maxFailureBackoff, "max-failure-backoff", 30*time.Minute,
+		"Ceiling of the requeue interval of applications whose imports keep failing.")
+	flag.BoolVar(&fetchFromPodEndpoint, "fetch-from-pod-endpoint", false,
+		"If set, swagger of labelled pods is fetched from the ready endpoint of the pod in the EndpointSlices "+
+			"of its Service instead of the Service, so the imported document matches the pod that was rolled out. "+
+			"Cannot be combined with --consistent-rollouts.")
+	flag.BoolVar(&consistentRollouts, "consistent-rollouts", false,
+		"If set, swagger is fetched from every ready endpoint of the Service and only imported when all of them serve "+
+			"the same document, or from the current ReplicaSet once the rollout of its Deployment is complete. "+
+			"Cannot be combined with --fetch-from-pod-endpoint.")
+	flag.StringVar(&optInLabel, "opt-in-label", "swaggerimporter",
+		"Key of the label opting pods into imports with the value true.")
+	flag.StringVar(&appLabel, "app-label", "app",
+		"Key of the label holding the application name of pods, e.g. app.kubernetes.io/name.")
+	flag.StringVar(&apiLabel, "api-label", "application",
+		"Key of the label holding the application name of APIs, e.g. app.kubernetes.io/part-of.")
+	flag.StringVar(&podSelector, "pod-selector", "",
+		"Label selector of the pods to import, e.g. app.kubernetes.io/part-of=shop. Replaces the opt-in label when set.")
+	flag.StringVar(&apiSelector, "api-selector", "",
+		"Label selector narrowing down the APIs matched by the application label, e.g. environment=production.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma separated namespaces of the pods, Services, SwaggerImports and namespaced APIs to watch. All namespaces when empty.")
+	flag.BoolVar(&restrictClusterAPIs, "restrict-cluster-apis", false,
+		"If set, cluster scoped APIs only accept imports from the namespaces in their swaggerimporter/allowed-namespaces "+
+			"or swaggerimporter/allowed-namespace-selector annotation. Otherwise APIs without these annotations accept imports from all namespaces.")
+	flag.StringVar(&versionStrategy, "version-strategy", controllers.VersionStrategyName,
+		"How the version of an API without spec.forProvider.version is resolved: name takes the major version after the last -v of the API name, "+
+			"regex the version capture group of --version-pattern, annotation the --version-key annotation or label "+
+			"of the API and semver the semantic version after the last -v of the API name.")
+	flag.StringVar(&versionPattern, "version-pattern", "",
+		"Regular expression with a capture group named version matched against API names by the regex version strategy, "+
+			`e.g. ^.+-(?P<version>v\d+)$.`)
+	flag.StringVar(&versionKey, "version-key", "",
+		"Annotation or label of APIs holding the version for the annotation version strategy.")
+	flag.StringVar(&versionTemplate, "version-template", "",
+		"Template rendering the resolved version into the {version} of the fetch path, with the {version}, {major}, "+
+			"{minor} and {patch} placeholders. Defaults to v{major}.0 for the name strategy, "+
+			"v{major}.{minor}.{patch} for semver and {version} otherwise.")
+	flag.BoolVar(&createVersions, "create-versions", false,
+		"If set, the next major versions beyond the newest API of an application are probed and an API is created "+
+			"for each version it publishes, copied from the newest API or the ConfigMap in its "+
+			"swaggerimporter/version-template annotation.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -77,6 +164,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// the endpoint of a single pod would bypass the check that every endpoint serves the same document
+	if fetchFromPodEndpoint && consistentRollouts {
+		setupLog.Error(errors.New("--fetch-from-pod-endpoint and --consistent-rollouts are mutually exclusive"), "invalid flags")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancelation and
@@ -97,8 +190,18 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
+	// restrict the cache to the watched namespaces, cluster scoped APIs are always watched
+	cacheOptions := cache.Options{}
+	if watchNamespaces != "" {
+		cacheOptions.DefaultNamespaces = map[string]cache.Config{}
+		for _, namespace := range strings.Split(watchNamespaces, ",") {
+			cacheOptions.DefaultNamespaces[strings.TrimSpace(namespace)] = cache.Config{}
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache:  cacheOptions,
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
@@ -125,13 +228,62 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.SwaggerImportReconciler{
-		Client:  mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Log:     ctrl.Log.WithName("controllers").WithName("SwaggerImport"),
-		HTTPGet: http.Get,
+	fetchTransport := http.DefaultTransport.(*http.Transport).Clone()
+	if fetchInsecureSkipVerify {
+		fetchTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- opt-in for self-signed workload certificates
+	}
+
+	swaggerImportReconciler := &controllers.SwaggerImportReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		Log:       ctrl.Log.WithName("controllers").WithName("SwaggerImport"),
+		Fetcher: &controllers.HTTPFetcher{
+			Transport: fetchTransport,
+			Timeout:   fetchTimeout,
+			MaxBytes:  fetchMaxBytes,
+			UserAgent: fetchUserAgent,
+		},
+
+		HoldBreakingChanges: holdBreakingChanges,
+		MaxFailureBackoff:   maxFailureBackoff,
+		FetchFromEndpoints:  fetchFromPodEndpoint,
+		ConsistentRollouts:  consistentRollouts,
+		CreateVersions:      createVersions,
+		RestrictClusterAPIs: restrictClusterAPIs,
+		Recorder:            mgr.GetEventRecorder("swagger-importer"),
+
+		OptInLabel: optInLabel,
+		AppLabel:   appLabel,
+		APILabel:   apiLabel,
+	}
+	if swaggerImportReconciler.PodSelector, err = labels.Parse(podSelector); err != nil {
+		setupLog.Error(err, "invalid pod selector", "selector", podSelector)
+		os.Exit(1)
+	}
+	if swaggerImportReconciler.APISelector, err = labels.Parse(apiSelector); err != nil {
+		setupLog.Error(err, "invalid API selector", "selector", apiSelector)
+		os.Exit(1)
+	}
+	if swaggerImportReconciler.Versions, err = controllers.NewVersionResolver(versionStrategy, versionPattern, versionKey, versionTemplate); err != nil {
+		setupLog.Error(err, "invalid version strategy", "strategy", versionStrategy)
+		os.Exit(1)
+	}
+	for _, field := range strings.Split(compareIgnoreFields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			swaggerImportReconciler.CompareIgnoreFields = append(swaggerImportReconciler.CompareIgnoreFields, field)
+		}
+	}
+	if enablePodLabelImport {
+		if err = swaggerImportReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SwaggerImport")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.SwaggerImportResourceReconciler{
+		SwaggerImportReconciler: swaggerImportReconciler,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "SwaggerImport")
+		setupLog.Error(err, "unable to create controller", "controller", "SwaggerImportResource")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// pendingHashAnnotation on an API holds the hash of a document with breaking changes awaiting approval
+	pendingHashAnnotation = "swaggerimporter/pending-hash"
+
+	// approvedHashAnnotation on an API approves the import of the document with the given hash
+	approvedHashAnnotation = "swaggerimporter/approved-hash"
+)
+
+// pendingApprovalError is returned when a document with breaking changes is held for approval
+type pendingApprovalError struct {
+	hash    string
+	changes []breakingChange
+}
+
+func (e *pendingApprovalError) Error() string {
+	return fmt.Sprintf("%d breaking changes pending approval, set annotation %s=%s on the API to import them",
+		len(e.changes), approvedHashAnnotation, e.hash)
+}
+
+// isPendingApproval reports whether err was caused by a document held for approval
+func isPendingApproval(err error) bool {
+	var pendingErr *pendingApprovalError
+	return errors.As(err, &pendingErr)
+}
+
+// documentHash identifies a document independently of key order, formatting and the ignored fields
+func documentHash(content string, ignoredFields []string) string {
+	if canonical, err := canonicalDocument(content, ignoredFields); err == nil {
+		content = canonical
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// holdBreakingChanges holds an update with breaking changes of an API within its version, unless the
+// document with the given hash has been approved. Every resolved version, a major version or e.g. a date,
+// is a single version of the API. APIs without a resolved version are not held.
+func holdBreakingChanges(version string, decision updateDecision, hash, approved string) updateDecision {
+	if !decision.Update || len(decision.BreakingChanges) == 0 {
+		return decision
+	}
+	if version == "" {
+		return decision
+	}
+	if approved == hash {
+		return decision
+	}
+
+	return updateDecision{
+		Update:          false,
+		Reason:          updateReasonPendingApproval,
+		BreakingChanges: decision.BreakingChanges,
+		PendingHash:     hash,
+	}
+}
+
+// recordPendingChange records the held document and its breaking changes on the API
+func (r *SwaggerImportReconciler) recordPendingChange(ctx context.Context, apiName, namespaceApi string, decision updateDecision) error {
+	api, key := apiObject(apiName, namespaceApi)
+	if err := r.Get(ctx, key, api); err != nil {
+		return err
+	}
+
+	annotations := api.GetAnnotations()
+	if annotations[pendingHashAnnotation] == decision.PendingHash {
+		return nil
+	}
+	patch := client.MergeFrom(api.DeepCopyObject().(client.Object))
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[pendingHashAnnotation] = decision.PendingHash
+	api.SetAnnotations(annotations)
+	recordBreakingChanges(api, decision.BreakingChanges)
+
+	if err := r.Patch(ctx, api, patch, client.FieldOwner(fieldManager)); err != nil {
+		return err
+	}
+
+	r.Log.Info("Breaking changes held for approval", "APIName", apiName, "ApiNamespace", namespaceApi, "PendingHash", decision.PendingHash)
+	return nil
+}
+
+// clearPendingChange removes a held document from the API once a document is imported
+func clearPendingChange(api client.Object) {
+	annotations := api.GetAnnotations()
+	if _, found := annotations[pendingHashAnnotation]; !found {
+		return
+	}
+
+	delete(annotations, pendingHashAnnotation)
+	api.SetAnnotations(annotations)
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("holdBreakingChanges", func() {
+	changes := []breakingChange{{Kind: changeRemovedPath, Location: "/refunds"}}
+	breaking := updateDecision{Update: true, Reason: updateReasonContentChanged, BreakingChanges: changes}
+
+	DescribeTable("should only hold unapproved breaking changes within a version",
+		func(version string, decision updateDecision, approved string, expected updateDecision) {
+			Expect(holdBreakingChanges(version, decision, "abc123", approved)).To(Equal(expected))
+		},
+		Entry("with breaking changes",
+			"v1.0", breaking, "",
+			updateDecision{Update: false, Reason: updateReasonPendingApproval, BreakingChanges: changes, PendingHash: "abc123"}),
+		Entry("with breaking changes approved for another document",
+			"v1.0", breaking, "def456",
+			updateDecision{Update: false, Reason: updateReasonPendingApproval, BreakingChanges: changes, PendingHash: "abc123"}),
+		Entry("with approved breaking changes",
+			"v1.0", breaking, "abc123",
+			breaking),
+		Entry("without breaking changes",
+			"v1.0", updateDecision{Update: true, Reason: updateReasonContentChanged}, "",
+			updateDecision{Update: true, Reason: updateReasonContentChanged}),
+		Entry("with a date version",
+			"2024-01-01", breaking, "",
+			updateDecision{Update: false, Reason: updateReasonPendingApproval, BreakingChanges: changes, PendingHash: "abc123"}),
+		Entry("without a version",
+			"", breaking, "",
+			breaking),
+	)
+})
+
+var _ = Describe("documentHash", func() {
+	It("should not depend on formatting or ignored fields", func() {
+		current := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0.0+build.41"}, "paths": {}}`
+		desired := "openapi: 3.0.1\ninfo:\n  title: Payments\n  version: 1.0.0+build.42\npaths: {}\n"
+
+		Expect(documentHash(current, []string{"info.version"})).To(Equal(documentHash(desired, []string{"info.version"})))
+		Expect(documentHash(current, nil)).NotTo(Equal(documentHash(desired, nil)))
+	})
+})
+
+var _ = Describe("importAnnotationChanged", func() {
+	api := func(annotations map[string]string) *namespacedapimanagement.API {
+		return &namespacedapimanagement.API{ObjectMeta: metav1.ObjectMeta{Name: "payments-v1", Annotations: annotations}}
+	}
+
+	DescribeTable("should only pass approvals and resync requests",
+		func(old, updated map[string]string, expected bool) {
+			Expect(importAnnotationChanged.Update(event.UpdateEvent{ObjectOld: api(old), ObjectNew: api(updated)})).To(Equal(expected))
+		},
+		Entry("with an approved hash",
+			map[string]string{pendingHashAnnotation: "abc123"},
+			map[string]string{pendingHashAnnotation: "abc123", approvedHashAnnotation: "abc123"},
+			true),
+		Entry("with a resync request",
+			map[string]string{resyncAnnotation: "1"},
+			map[string]string{resyncAnnotation: "2"},
+			true),
+		Entry("with annotations written by the importer",
+			map[string]string{},
+			map[string]string{pendingHashAnnotation: "abc123", lastSyncTimeAnnotation: "2026-10-16T20:00:00Z"},
+			false),
+	)
+})
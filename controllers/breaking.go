@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// breakingChangesAnnotation on an API lists the breaking changes of the last imported document
+const breakingChangesAnnotation = "swaggerimporter/breaking-changes"
+
+// maxRecordedBreakingChanges bounds the number of breaking changes recorded on an API
+const maxRecordedBreakingChanges = 20
+
+// changeKind classifies a breaking difference between two versions of a document
+type changeKind string
+
+const (
+	changeRemovedPath           changeKind = "RemovedPath"
+	changeRemovedOperation      changeKind = "RemovedOperation"
+	changeNewRequiredParameter  changeKind = "NewRequiredParameter"
+	changeNarrowedEnum          changeKind = "NarrowedEnum"
+	changeChangedRequestSchema  changeKind = "ChangedRequestSchema"
+	changeChangedResponseSchema changeKind = "ChangedResponseSchema"
+)
+
+// breakingChange is a difference that breaks existing clients of an API
+type breakingChange struct {
+	Kind     changeKind `json:"kind"`
+	Location string     `json:"location"`
+	Detail   string     `json:"detail,omitempty"`
+}
+
+func (c breakingChange) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("%s %s", c.Kind, c.Location)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, c.Location, c.Detail)
+}
+
+// detectBreakingChanges compares the current and the desired document of an API and returns the
+// differences that break existing clients: removed paths and operations, new required parameters,
+// narrowed enums and changed request or response schemas.
+func detectBreakingChanges(ctx context.Context, currentFormat, current, desiredFormat, desired string) ([]breakingChange, error) {
+	currentDoc, err := parseDocument(ctx, currentFormat, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current document: %v", err)
+	}
+	desiredDoc, err := parseDocument(ctx, desiredFormat, desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse desired document: %v", err)
+	}
+
+	var changes []breakingChange
+	currentPaths := currentDoc.Paths.Map()
+	for _, path := range sortedKeys(currentPaths) {
+		currentItem := currentPaths[path]
+		desiredItem := desiredDoc.Paths.Value(path)
+		if desiredItem == nil {
+			changes = append(changes, breakingChange{Kind: changeRemovedPath, Location: path})
+			continue
+		}
+
+		currentOperations := currentItem.Operations()
+		desiredOperations := desiredItem.Operations()
+		for _, method := range sortedKeys(currentOperations) {
+			location := method + " " + path
+			desiredOperation, found := desiredOperations[method]
+			if !found {
+				changes = append(changes, breakingChange{Kind: changeRemovedOperation, Location: location})
+				continue
+			}
+
+			changes = append(changes, compareOperations(location,
+				currentItem.Parameters, currentOperations[method],
+				desiredItem.Parameters, desiredOperation)...)
+		}
+	}
+
+	return changes, nil
+}
+
+// compareOperations compares the parameters, request body and responses of an operation
+func compareOperations(location string, currentPathParameters openapi3.Parameters, current *openapi3.Operation,
+	desiredPathParameters openapi3.Parameters, desired *openapi3.Operation) []breakingChange {
+	var changes []breakingChange
+
+	currentParameters := operationParameters(currentPathParameters, current)
+	desiredParameters := operationParameters(desiredPathParameters, desired)
+	for _, key := range sortedKeys(desiredParameters) {
+		parameter := desiredParameters[key]
+		previous, existed := currentParameters[key]
+		if parameter.Required && (!existed || !previous.Required) {
+			changes = append(changes, breakingChange{Kind: changeNewRequiredParameter, Location: location, Detail: "parameter " + key})
+		}
+		if existed {
+			changes = append(changes, compareSchemas(location, "parameter "+key, previous.Schema, parameter.Schema, true, map[[2]*openapi3.Schema]bool{})...)
+		}
+	}
+
+	if current.RequestBody != nil && desired.RequestBody != nil && current.RequestBody.Value != nil && desired.RequestBody.Value != nil {
+		if desired.RequestBody.Value.Required && !current.RequestBody.Value.Required {
+			changes = append(changes, breakingChange{Kind: changeNewRequiredParameter, Location: location, Detail: "request body"})
+		}
+		for _, mediaType := range sortedKeys(current.RequestBody.Value.Content) {
+			desiredMedia := desired.RequestBody.Value.Content[mediaType]
+			if desiredMedia == nil {
+				changes = append(changes, breakingChange{Kind: changeChangedRequestSchema, Location: location, Detail: "request body " + mediaType + " removed"})
+				continue
+			}
+			changes = append(changes, compareSchemas(location, "request body", current.RequestBody.Value.Content[mediaType].Schema, desiredMedia.Schema, true, map[[2]*openapi3.Schema]bool{})...)
+		}
+	} else if current.RequestBody == nil && desired.RequestBody != nil && desired.RequestBody.Value != nil && desired.RequestBody.Value.Required {
+		changes = append(changes, breakingChange{Kind: changeNewRequiredParameter, Location: location, Detail: "request body"})
+	}
+
+	currentResponses := current.Responses.Map()
+	for _, status := range sortedKeys(currentResponses) {
+		desiredResponse := desired.Responses.Value(status)
+		if desiredResponse == nil || desiredResponse.Value == nil {
+			changes = append(changes, breakingChange{Kind: changeChangedResponseSchema, Location: location, Detail: "response " + status + " removed"})
+			continue
+		}
+		if currentResponses[status].Value == nil {
+			continue
+		}
+
+		for _, mediaType := range sortedKeys(currentResponses[status].Value.Content) {
+			desiredMedia := desiredResponse.Value.Content[mediaType]
+			if desiredMedia == nil {
+				changes = append(changes, breakingChange{Kind: changeChangedResponseSchema, Location: location, Detail: "response " + status + " " + mediaType + " removed"})
+				continue
+			}
+			changes = append(changes, compareSchemas(location, "response "+status, currentResponses[status].Value.Content[mediaType].Schema, desiredMedia.Schema, false, map[[2]*openapi3.Schema]bool{})...)
+		}
+	}
+
+	return changes
+}
+
+// operationParameters returns the path and operation parameters of an operation keyed by location and name
+func operationParameters(pathParameters openapi3.Parameters, operation *openapi3.Operation) map[string]*openapi3.Parameter {
+	parameters := map[string]*openapi3.Parameter{}
+	for _, parameterRefs := range []openapi3.Parameters{pathParameters, operation.Parameters} {
+		for _, parameterRef := range parameterRefs {
+			if parameterRef == nil || parameterRef.Value == nil {
+				continue
+			}
+			parameters[parameterRef.Value.In+" "+parameterRef.Value.Name] = parameterRef.Value
+		}
+	}
+	return parameters
+}
+
+// compareSchemas compares two versions of a schema. Request schemas break clients when enums are narrowed,
+// properties become required or types change; response schemas break clients when properties are removed
+// or types change.
+func compareSchemas(location, subject string, current, desired *openapi3.SchemaRef, request bool, seen map[[2]*openapi3.Schema]bool) []breakingChange {
+	if current == nil || desired == nil || current.Value == nil || desired.Value == nil {
+		return nil
+	}
+
+	currentSchema, desiredSchema := current.Value, desired.Value
+	pair := [2]*openapi3.Schema{currentSchema, desiredSchema}
+	if seen[pair] {
+		return nil
+	}
+	seen[pair] = true
+
+	schemaChange := changeChangedResponseSchema
+	if request {
+		schemaChange = changeChangedRequestSchema
+	}
+
+	if !slices.Equal(currentSchema.Type.Slice(), desiredSchema.Type.Slice()) || currentSchema.Format != desiredSchema.Format {
+		return []breakingChange{{Kind: schemaChange, Location: location, Detail: fmt.Sprintf("%s type changed from %s to %s",
+			subject, schemaType(currentSchema), schemaType(desiredSchema))}}
+	}
+
+	var changes []breakingChange
+	if request && len(desiredSchema.Enum) > 0 {
+		for _, value := range currentSchema.Enum {
+			if !slices.ContainsFunc(desiredSchema.Enum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+				changes = append(changes, breakingChange{Kind: changeNarrowedEnum, Location: location, Detail: fmt.Sprintf("%s no longer accepts %v", subject, value)})
+			}
+		}
+		if len(currentSchema.Enum) == 0 {
+			changes = append(changes, breakingChange{Kind: changeNarrowedEnum, Location: location, Detail: subject + " is restricted to an enum"})
+		}
+	}
+
+	if request {
+		for _, property := range desiredSchema.Required {
+			if !slices.Contains(currentSchema.Required, property) {
+				changes = append(changes, breakingChange{Kind: changeNewRequiredParameter, Location: location, Detail: subject + " property " + property})
+			}
+		}
+	}
+
+	for _, property := range sortedKeys(currentSchema.Properties) {
+		desiredProperty, found := desiredSchema.Properties[property]
+		if !found {
+			if !request {
+				changes = append(changes, breakingChange{Kind: schemaChange, Location: location, Detail: subject + " property " + property + " removed"})
+			}
+			continue
+		}
+		changes = append(changes, compareSchemas(location, subject+" property "+property, currentSchema.Properties[property], desiredProperty, request, seen)...)
+	}
+
+	changes = append(changes, compareSchemas(location, subject+" items", currentSchema.Items, desiredSchema.Items, request, seen)...)
+
+	return changes
+}
+
+func schemaType(schema *openapi3.Schema) string {
+	typ := strings.Join(schema.Type.Slice(), "|")
+	if typ == "" {
+		typ = "any"
+	}
+	if schema.Format != "" {
+		typ += " (" + schema.Format + ")"
+	}
+	return typ
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// recordBreakingChanges stores the breaking changes of the imported document on the API,
+// or removes a previous record when there are none
+func recordBreakingChanges(api client.Object, changes []breakingChange) {
+	annotations := api.GetAnnotations()
+	if len(changes) == 0 {
+		if _, found := annotations[breakingChangesAnnotation]; found {
+			delete(annotations, breakingChangesAnnotation)
+			api.SetAnnotations(annotations)
+		}
+		return
+	}
+
+	if len(changes) > maxRecordedBreakingChanges {
+		changes = changes[:maxRecordedBreakingChanges]
+	}
+	recorded, err := json.Marshal(changes)
+	if err != nil {
+		return
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[breakingChangesAnnotation] = string(recorded)
+	api.SetAnnotations(annotations)
+}
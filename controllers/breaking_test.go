@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("detectBreakingChanges", func() {
+	ctx := context.Background()
+
+	current := `{
+  "openapi": "3.0.1",
+  "info": {"title": "Payments", "version": "1.0"},
+  "paths": {
+    "/payments": {
+      "get": {
+        "parameters": [{"name": "status", "in": "query", "schema": {"type": "string", "enum": ["open", "settled"]}}],
+        "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {
+          "type": "object", "properties": {"id": {"type": "string"}, "amount": {"type": "number"}}}}}}}
+      },
+      "post": {"responses": {"201": {"description": "Created"}}}
+    },
+    "/refunds": {"get": {"responses": {"200": {"description": "OK"}}}}
+  }
+}`
+
+	It("should not report identical documents", func() {
+		changes, err := detectBreakingChanges(ctx, formatOpenAPIJSON, current, formatOpenAPIJSON, current)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("should not report additions", func() {
+		desired := `{
+  "openapi": "3.0.1",
+  "info": {"title": "Payments", "version": "1.1"},
+  "paths": {
+    "/payments": {
+      "get": {
+        "parameters": [
+          {"name": "status", "in": "query", "schema": {"type": "string", "enum": ["open", "settled", "failed"]}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}}
+        ],
+        "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {
+          "type": "object", "properties": {"id": {"type": "string"}, "amount": {"type": "number"}, "currency": {"type": "string"}}}}}}}
+      },
+      "post": {"responses": {"201": {"description": "Created"}}}
+    },
+    "/refunds": {"get": {"responses": {"200": {"description": "OK"}}}},
+    "/disputes": {"get": {"responses": {"200": {"description": "OK"}}}}
+  }
+}`
+		changes, err := detectBreakingChanges(ctx, formatOpenAPIJSON, current, formatOpenAPIJSON, desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("should classify breaking changes", func() {
+		desired := `{
+  "openapi": "3.0.1",
+  "info": {"title": "Payments", "version": "2.0"},
+  "paths": {
+    "/payments": {
+      "get": {
+        "parameters": [
+          {"name": "status", "in": "query", "schema": {"type": "string", "enum": ["open"]}},
+          {"name": "merchant", "in": "query", "required": true, "schema": {"type": "string"}}
+        ],
+        "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {
+          "type": "object", "properties": {"id": {"type": "integer"}}}}}}}
+      }
+    }
+  }
+}`
+		changes, err := detectBreakingChanges(ctx, formatOpenAPIJSON, current, formatOpenAPIJSON, desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(ConsistOf(
+			breakingChange{Kind: changeRemovedPath, Location: "/refunds"},
+			breakingChange{Kind: changeRemovedOperation, Location: "POST /payments"},
+			breakingChange{Kind: changeNewRequiredParameter, Location: "GET /payments", Detail: "parameter query merchant"},
+			breakingChange{Kind: changeNarrowedEnum, Location: "GET /payments", Detail: "parameter query status no longer accepts settled"},
+			breakingChange{Kind: changeChangedResponseSchema, Location: "GET /payments", Detail: "response 200 property amount removed"},
+			breakingChange{Kind: changeChangedResponseSchema, Location: "GET /payments", Detail: "response 200 property id type changed from string to integer"},
+		))
+	})
+
+	It("should compare documents of different formats", func() {
+		swagger := `{"swagger": "2.0", "info": {"title": "Payments", "version": "1.0"},
+  "paths": {"/payments": {"get": {"responses": {"200": {"description": "OK"}}}}}}`
+		openapi := "openapi: 3.0.1\ninfo:\n  title: Payments\n  version: '2.0'\npaths: {}\n"
+
+		changes, err := detectBreakingChanges(ctx, formatSwaggerJSON, swagger, formatOpenAPIYAML, openapi)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(ConsistOf(breakingChange{Kind: changeRemovedPath, Location: "/payments"}))
+	})
+
+	It("should fail for documents that are not Swagger or OpenAPI", func() {
+		_, err := detectBreakingChanges(ctx, formatWSDL, "<definitions/>", formatOpenAPIJSON, current)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("recordBreakingChanges", func() {
+	It("should record and clear the breaking changes on the API", func() {
+		api := &namespacedapimanagement.API{ObjectMeta: metav1.ObjectMeta{Name: "payments-v1"}}
+		changes := []breakingChange{{Kind: changeRemovedPath, Location: "/refunds"}}
+
+		recordBreakingChanges(api, changes)
+		var recorded []breakingChange
+		Expect(json.Unmarshal([]byte(api.GetAnnotations()[breakingChangesAnnotation]), &recorded)).To(Succeed())
+		Expect(recorded).To(Equal(changes))
+
+		recordBreakingChanges(api, nil)
+		Expect(api.GetAnnotations()).NotTo(HaveKey(breakingChangesAnnotation))
+	})
+})
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// equivalentDocuments reports whether two documents describe the same contract. JSON and YAML documents
+// are compared after normalization, so key order and formatting do not matter, and the dotted field
+// paths in ignoredFields (e.g. info.version) are left out of the comparison. Documents that cannot be
+// normalized, such as WSDL, are compared as is.
+func equivalentDocuments(current, desired string, ignoredFields []string) bool {
+	if current == desired {
+		return true
+	}
+
+	canonicalCurrent, err := canonicalDocument(current, ignoredFields)
+	if err != nil {
+		return false
+	}
+	canonicalDesired, err := canonicalDocument(desired, ignoredFields)
+	if err != nil {
+		return false
+	}
+
+	return canonicalCurrent == canonicalDesired
+}
+
+// canonicalDocument returns the document as compact JSON with sorted keys and without the ignored fields
+func canonicalDocument(document string, ignoredFields []string) (string, error) {
+	// YAMLToJSON accepts JSON as well since JSON is a subset of YAML
+	documentJSON, err := yaml.YAMLToJSON([]byte(document))
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(documentJSON))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	if _, ok := value.(map[string]any); !ok {
+		return "", fmt.Errorf("document is not a JSON or YAML object")
+	}
+
+	for _, field := range ignoredFields {
+		removeField(value, strings.Split(field, "."))
+	}
+
+	// encoding/json writes map keys in sorted order
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(canonical), nil
+}
+
+// removeField deletes the field at the given path from a decoded JSON value
+func removeField(value any, path []string) {
+	object, ok := value.(map[string]any)
+	if !ok || len(path) == 0 {
+		return
+	}
+
+	if len(path) == 1 {
+		delete(object, path[0])
+		return
+	}
+
+	removeField(object[path[0]], path[1:])
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("equivalentDocuments", func() {
+	current := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0.0+build.41"}, "paths": {}}`
+
+	It("should ignore key order and whitespace", func() {
+		desired := `{
+  "paths": {},
+  "info": {"version": "1.0.0+build.41", "title": "Payments"},
+  "openapi": "3.0.1"
+}`
+		Expect(equivalentDocuments(current, desired, nil)).To(BeTrue())
+	})
+
+	It("should treat the same document in YAML as equivalent", func() {
+		desired := "openapi: 3.0.1\ninfo:\n  title: Payments\n  version: 1.0.0+build.41\npaths: {}\n"
+		Expect(equivalentDocuments(current, desired, nil)).To(BeTrue())
+	})
+
+	It("should detect contract changes", func() {
+		desired := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0.0+build.41"}, "paths": {"/refunds": {}}}`
+		Expect(equivalentDocuments(current, desired, nil)).To(BeFalse())
+	})
+
+	It("should only ignore the configured fields", func() {
+		desired := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0.0+build.42"}, "paths": {}}`
+		Expect(equivalentDocuments(current, desired, nil)).To(BeFalse())
+		Expect(equivalentDocuments(current, desired, []string{"info.version"})).To(BeTrue())
+	})
+
+	It("should compare documents that cannot be normalized as is", func() {
+		Expect(equivalentDocuments("<definitions/>", "<definitions />", nil)).To(BeFalse())
+	})
+})
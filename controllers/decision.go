@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// resyncAnnotation on an API forces the next fetched document to be imported when its value changes
+	resyncAnnotation = "swaggerimporter/resync"
+
+	// resyncedAnnotation records the resync annotation value that was last imported
+	resyncedAnnotation = "swaggerimporter/resynced"
+)
+
+// updateReason explains why the imported document of an API is or is not updated
+type updateReason string
+
+const (
+	updateReasonFirstImport    updateReason = "FirstImport"
+	updateReasonForcedResync   updateReason = "ForcedResync"
+	updateReasonFormatChanged  updateReason = "FormatChanged"
+	updateReasonContentChanged updateReason = "ContentChanged"
+	updateReasonUpToDate       updateReason = "UpToDate"
+
+	// updateReasonPendingApproval holds an update with breaking changes until it is approved
+	updateReasonPendingApproval updateReason = "PendingApproval"
+)
+
+// updateDecision is the outcome of comparing the import of an API with a fetched document
+type updateDecision struct {
+	Update bool
+	Reason updateReason
+
+	// BreakingChanges between the imported and the fetched document when the API is updated
+	BreakingChanges []breakingChange
+
+	// PendingHash is the hash of the fetched document when the update is held for approval
+	PendingHash string
+}
+
+// importState is the import currently declared on an API
+type importState struct {
+	contentFormat *string
+	contentValue  *string
+
+	// resync and resynced are the values of the resync annotations
+	resync   string
+	resynced string
+
+	// approved is the value of the approved hash annotation
+	approved string
+}
+
+// decideUpdate decides whether the fetched document must be imported into an API with the given state.
+// The rules are evaluated in order:
+//   - FirstImport when the API has no import or no content yet
+//   - ForcedResync when the resync annotation changed since the last import
+//   - FormatChanged when the content format differs
+//   - ContentChanged when the documents are not equivalent
+//   - UpToDate otherwise
+func decideUpdate(current importState, contentFormat, content string, ignoredFields []string) updateDecision {
+	switch {
+	case current.contentValue == nil:
+		return updateDecision{Update: true, Reason: updateReasonFirstImport}
+	case current.resync != "" && current.resync != current.resynced:
+		return updateDecision{Update: true, Reason: updateReasonForcedResync}
+	case current.contentFormat == nil || *current.contentFormat != contentFormat:
+		return updateDecision{Update: true, Reason: updateReasonFormatChanged}
+	case !equivalentDocuments(*current.contentValue, content, ignoredFields):
+		return updateDecision{Update: true, Reason: updateReasonContentChanged}
+	}
+	return updateDecision{Update: false, Reason: updateReasonUpToDate}
+}
+
+// apiUpdateDecision fetches the API and decides whether the fetched document must be imported into it
+func (r *SwaggerImportReconciler) apiUpdateDecision(ctx context.Context, apiName, namespaceApi, contentFormat, content string) (updateDecision, error) {
+	var current importState
+	var version string
+
+	if namespaceApi == "" {
+		api := &clusterapimanagement.API{}
+		if err := r.Get(ctx, client.ObjectKey{Name: apiName}, api); err != nil {
+			return updateDecision{}, err
+		}
+
+		if api.Spec.ForProvider.Import != nil {
+			current.contentFormat = api.Spec.ForProvider.Import.ContentFormat
+			current.contentValue = api.Spec.ForProvider.Import.ContentValue
+		}
+		current.resync = api.GetAnnotations()[resyncAnnotation]
+		current.resynced = api.GetAnnotations()[resyncedAnnotation]
+		current.approved = api.GetAnnotations()[approvedHashAnnotation]
+		version, _ = r.apiVersion(api)
+	} else {
+		api := &namespacedapimanagement.API{}
+		if err := r.Get(ctx, client.ObjectKey{Name: apiName, Namespace: namespaceApi}, api); err != nil {
+			return updateDecision{}, err
+		}
+
+		if api.Spec.ForProvider.Import != nil {
+			current.contentFormat = api.Spec.ForProvider.Import.ContentFormat
+			current.contentValue = api.Spec.ForProvider.Import.ContentValue
+		}
+		current.resync = api.GetAnnotations()[resyncAnnotation]
+		current.resynced = api.GetAnnotations()[resyncedAnnotation]
+		current.approved = api.GetAnnotations()[approvedHashAnnotation]
+		version, _ = r.apiVersion(api)
+	}
+
+	decision := decideUpdate(current, contentFormat, content, r.CompareIgnoreFields)
+	if decision.Update && current.contentFormat != nil && current.contentValue != nil {
+		changes, err := detectBreakingChanges(ctx, *current.contentFormat, *current.contentValue, contentFormat, content)
+		if err != nil {
+			r.Log.V(1).Info("Breaking changes not detected", "APIName", apiName, "Reason", err.Error())
+		}
+		decision.BreakingChanges = changes
+	}
+	if r.HoldBreakingChanges {
+		// APIs without a resolvable version are not held
+		decision = holdBreakingChanges(version, decision, documentHash(content, r.CompareIgnoreFields), current.approved)
+	}
+	r.Log.Info("Update decision", "APIName", apiName, "ApiNamespace", namespaceApi, "Update", decision.Update, "Reason", decision.Reason,
+		"BreakingChanges", len(decision.BreakingChanges))
+
+	return decision, nil
+}
+
+// markResynced records the resync annotation value being imported so the resync is not repeated
+func markResynced(api client.Object) {
+	annotations := api.GetAnnotations()
+	resync, found := annotations[resyncAnnotation]
+	if !found {
+		return
+	}
+
+	annotations[resyncedAnnotation] = resync
+	api.SetAnnotations(annotations)
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("decideUpdate", func() {
+	content := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+	format := formatOpenAPIJSON
+
+	ptr := func(s string) *string { return &s }
+
+	DescribeTable("should decide deterministically for every existing state",
+		func(current importState, expected updateDecision) {
+			Expect(decideUpdate(current, format, content, nil)).To(Equal(expected))
+		},
+		Entry("without an import",
+			importState{},
+			updateDecision{Update: true, Reason: updateReasonFirstImport}),
+		Entry("with an import without content",
+			importState{contentFormat: ptr(format)},
+			updateDecision{Update: true, Reason: updateReasonFirstImport}),
+		Entry("with a pending resync",
+			importState{contentFormat: ptr(format), contentValue: ptr(content), resync: "2024-05-01"},
+			updateDecision{Update: true, Reason: updateReasonForcedResync}),
+		Entry("with an already applied resync",
+			importState{contentFormat: ptr(format), contentValue: ptr(content), resync: "2024-05-01", resynced: "2024-05-01"},
+			updateDecision{Update: false, Reason: updateReasonUpToDate}),
+		Entry("without a content format",
+			importState{contentValue: ptr(content)},
+			updateDecision{Update: true, Reason: updateReasonFormatChanged}),
+		Entry("with another content format",
+			importState{contentFormat: ptr(formatSwaggerJSON), contentValue: ptr(content)},
+			updateDecision{Update: true, Reason: updateReasonFormatChanged}),
+		Entry("with other content",
+			importState{contentFormat: ptr(format), contentValue: ptr(`{"openapi": "3.0.1", "paths": {}}`)},
+			updateDecision{Update: true, Reason: updateReasonContentChanged}),
+		Entry("with the same content",
+			importState{contentFormat: ptr(format), contentValue: ptr(content)},
+			updateDecision{Update: false, Reason: updateReasonUpToDate}),
+	)
+})
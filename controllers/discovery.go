@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// wellKnownPathTemplates are the default swagger locations of common frameworks, in the order they are probed
+var wellKnownPathTemplates = []string{
+	defaultPathTemplate,              // Swashbuckle and NSwag
+	"/v3/api-docs",                   // springdoc
+	"/v2/api-docs",                   // springfox
+	"/openapi.json",                  // FastAPI
+	"/api-json",                      // NestJS
+	"/swagger/v{major}/swagger.json", // NSwag with the default document name
+	"/swagger.json",                  // go-swagger
+	"/.well-known/openapi",
+}
+
+// discoveryTemplates returns the locations to probe for a source without a configured location.
+// The location that served the swagger document last time is probed first.
+func (r *SwaggerImportReconciler) discoveryTemplates(source swaggerSource) []string {
+	remembered, found := r.discoveredTemplates.Load(discoveryKey(source))
+	if !found {
+		return wellKnownPathTemplates
+	}
+
+	templates := []string{remembered.(string)}
+	for _, template := range wellKnownPathTemplates {
+		if template != remembered {
+			templates = append(templates, template)
+		}
+	}
+	return templates
+}
+
+// rememberTemplate records the location that served the swagger document of a source
+func (r *SwaggerImportReconciler) rememberTemplate(source swaggerSource, template string) {
+	previous, loaded := r.discoveredTemplates.Swap(discoveryKey(source), template)
+	if !loaded || previous != template {
+		r.Log.Info("Discovered swagger location", "service", source.service, "namespace", source.namespace, "template", template)
+	}
+}
+
+// discoveryKey keys the remembered location by the version of a source, as versions may be served at different locations
+func discoveryKey(source swaggerSource) string {
+	return source.namespace + "/" + source.service + "/" + source.version
+}
+
+// versionedTemplate reports whether a location contains the version of the source it is fetched for
+func versionedTemplate(template string) bool {
+	return strings.Contains(template, "{version}") || strings.Contains(template, "{major}")
+}
+
+// claimLocation checks that a document fetched from a location without the version may be imported into the
+// API of the version of the source. Documents whose info.version has the major version of the source are
+// accepted. Others, e.g. with the 0.1.0 default of FastAPI, are accepted unless another version of the
+// application took its document from the location, so one document is not imported into two versions.
+func (r *SwaggerImportReconciler) claimLocation(ctx context.Context, source swaggerSource, template, contentFormat, content string) error {
+	key := source.namespace + "/" + source.service + "/" + template
+	if err := servesVersion(ctx, contentFormat, content, source.version); err == nil {
+		r.claimedLocations.Store(key, source.version)
+		return nil
+	}
+
+	claimed, loaded := r.claimedLocations.LoadOrStore(key, source.version)
+	if loaded && claimed != source.version {
+		return fmt.Errorf("location serves the document of version %s", claimed)
+	}
+	return nil
+}
+
+// servesVersion checks that a document fetched from a location without the version is a document of the
+// major version
+func servesVersion(ctx context.Context, contentFormat, content, version string) error {
+	doc, err := parseDocument(ctx, contentFormat, content)
+	if err != nil {
+		return err
+	}
+	if doc.Info == nil || doc.Info.Version == "" {
+		return fmt.Errorf("document has no version, not version %s", version)
+	}
+	if !sameMajorVersion(doc.Info.Version, version) {
+		return fmt.Errorf("document has version %s, not version %s", doc.Info.Version, version)
+	}
+	return nil
+}
+
+// sameMajorVersion reports whether two versions have the same numeric major version. Versions without
+// a numeric major version, e.g. dates, must be equal apart from a "v" prefix.
+func sameMajorVersion(documentVersion, version string) bool {
+	documentMajor, documentErr := versionMajor(documentVersion)
+	major, err := versionMajor(version)
+	if documentErr != nil || err != nil {
+		return strings.TrimPrefix(documentVersion, "v") == strings.TrimPrefix(version, "v")
+	}
+	return documentMajor == major
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("Swagger location discovery", func() {
+	It("should probe well-known locations and remember the one that worked", func() {
+		var requestedURLs []string
+		reconciler := &SwaggerImportReconciler{
+			Log: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				requestedURLs = append(requestedURLs, url)
+				if strings.HasSuffix(url, "/openapi.json") {
+					return []byte(`{"openapi": "3.1.0", "info": {"title": "FastAPI", "version": "1.2.0"}}`), nil
+				}
+				return nil, &statusError{url: url, statusCode: http.StatusNotFound}
+			}),
+		}
+
+		source := swaggerSource{
+			namespace: "services",
+			service:   "fastapi-app",
+			ports:     []int32{8000},
+			version:   "v1.0",
+		}
+
+		swaggerJSON, err := reconciler.fetchFromSource(context.Background(), source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swaggerJSON).To(Equal(`{"openapi": "3.1.0", "info": {"title": "FastAPI", "version": "1.2.0"}}`))
+		Expect(requestedURLs).To(HaveLen(4))
+
+		requestedURLs = nil
+		_, err = reconciler.fetchFromSource(context.Background(), source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requestedURLs).To(Equal([]string{"http://fastapi-app.services.svc.cluster.local:8000/openapi.json"}))
+	})
+
+	It("should skip locations that do not serve a swagger document", func() {
+		reconciler := &SwaggerImportReconciler{
+			Log: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				if strings.HasSuffix(url, "/v3/api-docs") {
+					return []byte(`{"openapi": "3.0.1", "info": {"title": "Spring", "version": "v1"}}`), nil
+				}
+				// catch-all route of a single page application
+				return []byte(`<!DOCTYPE html><html><body>app</body></html>`), nil
+			}),
+		}
+
+		source := swaggerSource{
+			namespace: "services",
+			service:   "spring-app",
+			ports:     []int32{8080},
+			version:   "v1.0",
+		}
+
+		swaggerJSON, err := reconciler.fetchFromSource(context.Background(), source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swaggerJSON).To(Equal(`{"openapi": "3.0.1", "info": {"title": "Spring", "version": "v1"}}`))
+		Expect(reconciler.discoveryTemplates(source)[0]).To(Equal("/v3/api-docs"))
+	})
+
+	It("should only accept documents of the version of the API at locations without the version", func() {
+		reconciler := &SwaggerImportReconciler{
+			Log: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				if strings.HasSuffix(url, "/v3/api-docs") {
+					return []byte(`{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.4.0"}}`), nil
+				}
+				return nil, &statusError{url: url, statusCode: http.StatusNotFound}
+			}),
+		}
+
+		v1 := swaggerSource{namespace: "services", service: "payments", ports: []int32{8080}, version: "v1.0"}
+		v2 := v1
+		v2.version = "v2.0"
+
+		swaggerJSON, err := reconciler.fetchFromSource(context.Background(), v1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swaggerJSON).To(ContainSubstring(`"version": "1.4.0"`))
+
+		_, err = reconciler.fetchFromSource(context.Background(), v2)
+		Expect(err).To(HaveOccurred())
+		Expect(reconciler.discoveryTemplates(v1)[0]).To(Equal("/v3/api-docs"))
+		Expect(reconciler.discoveryTemplates(v2)[0]).To(Equal(defaultPathTemplate))
+	})
+
+	DescribeTable("should discover documents with the default version of their framework",
+		func(location, document string) {
+			reconciler := &SwaggerImportReconciler{
+				Log: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					if strings.HasSuffix(url, location) {
+						return []byte(document), nil
+					}
+					return nil, &statusError{url: url, statusCode: http.StatusNotFound}
+				}),
+			}
+
+			v1 := swaggerSource{namespace: "services", service: "payments", ports: []int32{8080}, version: "v1.0"}
+			v2 := v1
+			v2.version = "v2.0"
+
+			swaggerJSON, err := reconciler.fetchFromSource(context.Background(), v1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swaggerJSON).To(Equal(document))
+
+			// the document was already imported into the first version
+			_, err = reconciler.fetchFromSource(context.Background(), v2)
+			Expect(err).To(HaveOccurred())
+
+			_, err = reconciler.fetchFromSource(context.Background(), v1)
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("FastAPI", "/openapi.json", `{"openapi": "3.1.0", "info": {"title": "FastAPI", "version": "0.1.0"}, "paths": {}}`),
+		Entry("springdoc", "/v3/api-docs", `{"openapi": "3.0.1", "info": {"title": "OpenAPI definition", "version": "v0"}, "paths": {}}`),
+	)
+
+	It("should probe the NSwag location of the major version of each API", func() {
+		reconciler := &SwaggerImportReconciler{
+			Log: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				switch {
+				case strings.HasSuffix(url, "/swagger/v1/swagger.json"):
+					return []byte(`{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}}`), nil
+				case strings.HasSuffix(url, "/swagger/v2/swagger.json"):
+					return []byte(`{"openapi": "3.0.1", "info": {"title": "Payments", "version": "2.0"}}`), nil
+				}
+				return nil, &statusError{url: url, statusCode: http.StatusNotFound}
+			}),
+		}
+
+		v1 := swaggerSource{namespace: "services", service: "payments", ports: []int32{8080}, version: "v1.0"}
+		v2 := v1
+		v2.version = "v2.0"
+
+		swaggerJSON, err := reconciler.fetchFromSource(context.Background(), v1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swaggerJSON).To(ContainSubstring(`"version": "1.0"`))
+
+		swaggerJSON, err = reconciler.fetchFromSource(context.Background(), v2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swaggerJSON).To(ContainSubstring(`"version": "2.0"`))
+	})
+
+	It("should not probe other locations when a location is configured", func() {
+		var requestedURLs []string
+		reconciler := &SwaggerImportReconciler{
+			Log: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				requestedURLs = append(requestedURLs, url)
+				return nil, &statusError{url: url, statusCode: http.StatusNotFound}
+			}),
+		}
+
+		source := swaggerSource{
+			namespace: "services",
+			service:   "spring-app",
+			ports:     []int32{8080},
+			template:  "/v3/api-docs",
+		}
+
+		_, err := reconciler.fetchFromSource(context.Background(), source)
+		Expect(err).To(HaveOccurred())
+		Expect(requestedURLs).To(HaveLen(1))
+	})
+})
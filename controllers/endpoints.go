@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// endpointPollInterval is the requeue interval while a ready pod is not yet a ready endpoint of its Service
+const endpointPollInterval = 5 * time.Second
+
+// endpoint is a ready address behind the Service of an application
+type endpoint struct {
+	address string
+
+	// pod is the name of the pod serving the address, empty for endpoints that are not pods
+	pod string
+
+	// ports are the target ports of the Service on the address
+	ports []int32
+}
+
+// isPodReady reports whether a pod is ready to serve requests and not terminating
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// readyEndpoints returns the ready endpoints of a Service from its EndpointSlices, ordered by pod and address
+func (r *SwaggerImportReconciler) readyEndpoints(ctx context.Context, namespace, service string) ([]endpoint, error) {
+	var endpointSlices discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &endpointSlices, client.InNamespace(namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: service}); err != nil {
+		return nil, err
+	}
+
+	var endpoints []endpoint
+	for _, endpointSlice := range endpointSlices.Items {
+		var ports []int32
+		for _, port := range endpointSlice.Ports {
+			if port.Port == nil || (port.Protocol != nil && *port.Protocol != corev1.ProtocolTCP) {
+				continue
+			}
+			ports = append(ports, *port.Port)
+		}
+		if len(ports) == 0 {
+			continue
+		}
+
+		for _, ep := range endpointSlice.Endpoints {
+			// a missing ready condition means the endpoint is ready
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			if len(ep.Addresses) == 0 {
+				continue
+			}
+
+			readyEndpoint := endpoint{address: ep.Addresses[0], ports: ports}
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				readyEndpoint.pod = ep.TargetRef.Name
+			}
+			endpoints = append(endpoints, readyEndpoint)
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].pod != endpoints[j].pod {
+			return endpoints[i].pod < endpoints[j].pod
+		}
+		return endpoints[i].address < endpoints[j].address
+	})
+	return endpoints, nil
+}
+
+// podEndpoint returns the ready endpoint of a pod behind the Service of an application
+func (r *SwaggerImportReconciler) podEndpoint(ctx context.Context, pod *corev1.Pod, service string) (*endpoint, error) {
+	endpoints, err := r.readyEndpoints(ctx, pod.Namespace, service)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range endpoints {
+		if endpoints[i].pod == pod.Name {
+			return &endpoints[i], nil
+		}
+	}
+	return nil, nil
+}
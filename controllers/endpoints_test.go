@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("isPodReady", func() {
+	It("should only accept ready pods that are not terminating", func() {
+		pod := &corev1.Pod{}
+		Expect(isPodReady(pod)).To(BeFalse())
+
+		pod.Status = readyPodStatus
+		Expect(isPodReady(pod)).To(BeTrue())
+
+		pod.DeletionTimestamp = &metav1.Time{}
+		Expect(isPodReady(pod)).To(BeFalse())
+
+		notReady := &corev1.Pod{Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+		}}
+		Expect(isPodReady(notReady)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Ready endpoints", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *SwaggerImportReconciler
+		fetched    []string
+		pod        *corev1.Pod
+	)
+
+	swaggerJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+
+	endpointSlice := func(name string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "services",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "payments"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       []discoveryv1.EndpointPort{{Port: ptr.To[int32](8081), Protocol: ptr.To(corev1.ProtocolTCP)}},
+			Endpoints:   endpoints,
+		}
+	}
+
+	podEndpoint := func(address, pod string, ready bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(ready)},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "services"},
+		}
+	}
+
+	newReconciler := func(objects ...client.Object) {
+		app := newApplication("payments", "services")
+		app.pod = pod
+		app.service.Annotations = map[string]string{pathAnnotation: "/openapi.json"}
+		app.service.Spec.Ports = []corev1.ServicePort{{Port: 80}}
+
+		reconciler = newTestReconciler(func(ctx context.Context, url string) ([]byte, error) {
+			fetched = append(fetched, url)
+			return []byte(swaggerJSON), nil
+		}, append(objects, app.objects()...)...)
+		reconciler.FetchFromEndpoints = true
+		fakeClient = reconciler.Client
+	}
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "payments", Namespace: pod.Namespace},
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fetched = nil
+		pod = newApplication("payments", "services").pod
+	})
+
+	It("should list the ready endpoints of the service in order", func() {
+		newReconciler(
+			endpointSlice("payments-b", podEndpoint("10.0.0.3", "payments-c", true), podEndpoint("10.0.0.4", "payments-d", false)),
+			endpointSlice("payments-a", podEndpoint("10.0.0.2", "payments-b", true), podEndpoint("10.0.0.1", "payments-a", true)),
+		)
+
+		endpoints, err := reconciler.readyEndpoints(ctx, "services", "payments")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal([]endpoint{
+			{address: "10.0.0.1", pod: "payments-a", ports: []int32{8081}},
+			{address: "10.0.0.2", pod: "payments-b", ports: []int32{8081}},
+			{address: "10.0.0.3", pod: "payments-c", ports: []int32{8081}},
+		}))
+	})
+
+	It("should not fetch from pods that are not ready", func() {
+		pod.Status = corev1.PodStatus{}
+		newReconciler(endpointSlice("payments-a", podEndpoint("10.0.0.1", pod.Name, true)))
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(fetched).To(BeEmpty())
+	})
+
+	It("should fetch from the endpoint of the pod on the target port", func() {
+		newReconciler(endpointSlice("payments-a",
+			podEndpoint("10.0.0.1", "payments-old", true),
+			podEndpoint("10.0.0.2", pod.Name, true),
+		))
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched).To(Equal([]string{"http://10.0.0.2:8081/openapi.json"}))
+
+		api := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v1", Namespace: "services"}, api)).To(Succeed())
+		Expect(*api.Spec.ForProvider.Import.ContentValue).To(Equal(swaggerJSON))
+	})
+
+	It("should wait until the pod is a ready endpoint of the service", func() {
+		newReconciler(endpointSlice("payments-a",
+			podEndpoint("10.0.0.1", "payments-old", true),
+			podEndpoint("10.0.0.2", pod.Name, false),
+		))
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(endpointPollInterval))
+		Expect(fetched).To(BeEmpty())
+	})
+})
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of the events recorded for import attempts
+const (
+	eventFetched           = "Fetched"
+	eventImported          = "Imported"
+	eventUpToDate          = "UpToDate"
+	eventFetchFailed       = "FetchFailed"
+	eventValidationFailed  = "ValidationFailed"
+	eventImportFailed      = "ImportFailed"
+	eventPendingApproval   = "PendingApproval"
+	eventRolloutInProgress = "RolloutInProgress"
+	eventImportDenied      = "ImportDenied"
+	eventVersionCreated    = "VersionCreated"
+)
+
+const (
+	// lastSyncTimeAnnotation on an API records when a document was last imported
+	lastSyncTimeAnnotation = "swaggerimporter/last-sync-time"
+
+	// specHashAnnotation on an API records the hash of the imported document
+	specHashAnnotation = "swaggerimporter/spec-hash"
+
+	// lastErrorAnnotation on an API records why the last import attempt failed
+	lastErrorAnnotation = "swaggerimporter/last-error"
+)
+
+// apiObject returns an empty API of the kind selected by the namespace and its key.
+// APIs without a namespace are cluster scoped.
+func apiObject(apiName, namespaceApi string) (client.Object, client.ObjectKey) {
+	if namespaceApi == "" {
+		return &clusterapimanagement.API{}, client.ObjectKey{Name: apiName}
+	}
+	return &namespacedapimanagement.API{}, client.ObjectKey{Name: apiName, Namespace: namespaceApi}
+}
+
+// recordEvent records an event on the object that triggered the import, e.g. a Pod or a SwaggerImport,
+// and on the API. Events are only recorded when the reconciler has a recorder.
+func (r *SwaggerImportReconciler) recordEvent(ctx context.Context, trigger client.Object, apiName, namespaceApi, eventtype, reason, note string) {
+	if r.Recorder == nil {
+		return
+	}
+
+	api, key := apiObject(apiName, namespaceApi)
+	if err := r.Get(ctx, key, api); err != nil {
+		api = nil
+	}
+
+	if trigger != nil {
+		r.Recorder.Eventf(trigger, api, eventtype, reason, "Import", "%s", note)
+	}
+	if api != nil {
+		r.Recorder.Eventf(api, trigger, eventtype, reason, "Import", "%s", note)
+	}
+}
+
+// recordFailure records the error of a failed import attempt on the API
+func (r *SwaggerImportReconciler) recordFailure(ctx context.Context, trigger client.Object, apiName, namespaceApi, reason string, importErr error) {
+	r.recordEvent(ctx, trigger, apiName, namespaceApi, corev1.EventTypeWarning, reason, importErr.Error())
+
+	api, key := apiObject(apiName, namespaceApi)
+	if err := r.Get(ctx, key, api); err != nil {
+		return
+	}
+
+	annotations := api.GetAnnotations()
+	if annotations[lastErrorAnnotation] == importErr.Error() {
+		return
+	}
+	patch := client.MergeFrom(api.DeepCopyObject().(client.Object))
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[lastErrorAnnotation] = importErr.Error()
+	api.SetAnnotations(annotations)
+
+	if err := r.Patch(ctx, api, patch, client.FieldOwner(fieldManager)); err != nil {
+		r.Log.Error(err, "Failed to record import error", "APIName", apiName, "ApiNamespace", namespaceApi)
+	}
+}
+
+// clearFailure removes the error of a previous import attempt from the API
+func (r *SwaggerImportReconciler) clearFailure(ctx context.Context, apiName, namespaceApi string) error {
+	api, key := apiObject(apiName, namespaceApi)
+	if err := r.Get(ctx, key, api); err != nil {
+		return err
+	}
+
+	annotations := api.GetAnnotations()
+	if _, found := annotations[lastErrorAnnotation]; !found {
+		return nil
+	}
+	patch := client.MergeFrom(api.DeepCopyObject().(client.Object))
+
+	delete(annotations, lastErrorAnnotation)
+	api.SetAnnotations(annotations)
+
+	return r.Patch(ctx, api, patch, client.FieldOwner(fieldManager))
+}
+
+// markSynced records the time and the hash of an imported document on the API and clears the last error
+func markSynced(api client.Object, hash string) {
+	annotations := api.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[lastSyncTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	annotations[specHashAnnotation] = hash
+	delete(annotations, lastErrorAnnotation)
+	api.SetAnnotations(annotations)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recordedEvent is an event recorded by the eventRecorder
+type recordedEvent struct {
+	Regarding string
+	Type      string
+	Reason    string
+}
+
+// eventRecorder records the kind of the regarding object and the reason of every event, and the formatted notes
+type eventRecorder struct {
+	events []recordedEvent
+	notes  []string
+}
+
+func (e *eventRecorder) Eventf(regarding runtime.Object, related runtime.Object, eventtype, reason, action, note string, args ...interface{}) {
+	kind := "Unknown"
+	switch regarding.(type) {
+	case *corev1.Pod:
+		kind = "Pod"
+	case *namespacedapimanagement.API, *clusterapimanagement.API:
+		kind = "API"
+	}
+	e.events = append(e.events, recordedEvent{Regarding: kind, Type: eventtype, Reason: reason})
+	e.notes = append(e.notes, fmt.Sprintf(note, args...))
+}
+
+var _ = Describe("Import events", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *SwaggerImportReconciler
+		recorder   *eventRecorder
+		statusCode int
+		req        ctrl.Request
+		apiKey     types.NamespacedName
+	)
+
+	swaggerJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		app := newApplication("payments", "services")
+		app.service.Annotations = map[string]string{pathAnnotation: "/openapi.json"}
+
+		recorder = &eventRecorder{}
+		statusCode = http.StatusOK
+		reconciler = newTestReconciler(func(ctx context.Context, url string) ([]byte, error) {
+			if statusCode != http.StatusOK {
+				return nil, &statusError{url: url, statusCode: statusCode}
+			}
+			return []byte(swaggerJSON), nil
+		}, app.objects()...)
+		reconciler.Recorder = recorder
+		fakeClient = reconciler.Client
+
+		req = ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+		apiKey = client.ObjectKeyFromObject(app.api)
+	})
+
+	It("should record imports on the Pod and the API", func() {
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.events).To(Equal([]recordedEvent{
+			{Regarding: "Pod", Type: corev1.EventTypeNormal, Reason: eventFetched},
+			{Regarding: "API", Type: corev1.EventTypeNormal, Reason: eventFetched},
+			{Regarding: "Pod", Type: corev1.EventTypeNormal, Reason: eventImported},
+			{Regarding: "API", Type: corev1.EventTypeNormal, Reason: eventImported},
+		}))
+
+		api := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, apiKey, api)).To(Succeed())
+		Expect(api.GetAnnotations()).To(HaveKey(lastSyncTimeAnnotation))
+		Expect(api.GetAnnotations()).To(HaveKeyWithValue(specHashAnnotation, documentHash(swaggerJSON, nil)))
+
+		recorder.events = nil
+		_, err = reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.events).To(ContainElement(recordedEvent{Regarding: "API", Type: corev1.EventTypeNormal, Reason: eventUpToDate}))
+	})
+
+	It("should record failures on the Pod and the API", func() {
+		statusCode = http.StatusNotFound
+
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.events).To(Equal([]recordedEvent{
+			{Regarding: "Pod", Type: corev1.EventTypeWarning, Reason: eventFetchFailed},
+			{Regarding: "API", Type: corev1.EventTypeWarning, Reason: eventFetchFailed},
+		}))
+
+		api := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, apiKey, api)).To(Succeed())
+		Expect(api.GetAnnotations()).To(HaveKeyWithValue(lastErrorAnnotation, ContainSubstring("HTTP status: 404")))
+
+		statusCode = http.StatusOK
+		_, err = reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeClient.Get(ctx, apiKey, api)).To(Succeed())
+		Expect(api.GetAnnotations()).NotTo(HaveKey(lastErrorAnnotation))
+	})
+
+	It("should record notes containing percent signs as they are", func() {
+		statusCode = http.StatusNotFound
+		service := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, req.NamespacedName, service)).To(Succeed())
+		service.Annotations = map[string]string{pathAnnotation: "/docs%2Fopenapi.json"}
+		Expect(fakeClient.Update(ctx, service)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.notes).To(HaveEach(ContainSubstring("/docs%2Fopenapi.json")))
+		Expect(recorder.notes).NotTo(ContainElement(ContainSubstring("%!")))
+	})
+})
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultFetchTimeout bounds a fetch when the HTTPFetcher does not set a timeout
+	defaultFetchTimeout = 10 * time.Second
+
+	// defaultFetchMaxBytes bounds the size of a fetched document when the HTTPFetcher does not set a limit
+	defaultFetchMaxBytes = 10 << 20
+)
+
+// Fetcher fetches the swagger document at a URL
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// FetcherFunc adapts a function to a Fetcher
+type FetcherFunc func(ctx context.Context, url string) ([]byte, error)
+
+// Fetch calls f(ctx, url)
+func (f FetcherFunc) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return f(ctx, url)
+}
+
+// statusError is returned when a swagger document is not served with HTTP status 200
+type statusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("swagger not found or invalid: %s, HTTP status: %d", e.url, e.statusCode)
+}
+
+// HTTPFetcher fetches swagger documents over HTTP with a timeout per request and a limit on the response size
+type HTTPFetcher struct {
+	// Transport of the requests, http.DefaultTransport when nil
+	Transport http.RoundTripper
+
+	// Timeout of a request including reading the response, defaultFetchTimeout when not positive
+	Timeout time.Duration
+
+	// MaxBytes is the maximum size of a document, defaultFetchMaxBytes when not positive
+	MaxBytes int64
+
+	// UserAgent of the requests
+	UserAgent string
+}
+
+// Fetch fetches the document at url
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+	maxBytes := f.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultFetchMaxBytes
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	client := &http.Client{Transport: f.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{url: url, statusCode: resp.StatusCode}
+	}
+
+	// read one byte more than allowed to detect documents exceeding the limit
+	document, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(document)) > maxBytes {
+		return nil, fmt.Errorf("swagger document at %s exceeds the limit of %d bytes", url, maxBytes)
+	}
+
+	return document, nil
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPFetcher", func() {
+	var (
+		server  *httptest.Server
+		handler http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should fetch the document with the user agent", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("User-Agent")).To(Equal("swagger-importer"))
+			_, _ = w.Write([]byte(`{"openapi": "3.0.1"}`))
+		}
+
+		fetcher := &HTTPFetcher{UserAgent: "swagger-importer"}
+		document, err := fetcher.Fetch(context.Background(), server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(document)).To(Equal(`{"openapi": "3.0.1"}`))
+	})
+
+	It("should fail for other statuses than 200", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		_, err := (&HTTPFetcher{}).Fetch(context.Background(), server.URL)
+		var statusErr *statusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.statusCode).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should fail for documents exceeding the size limit", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(strings.Repeat("a", 11)))
+		}
+
+		_, err := (&HTTPFetcher{MaxBytes: 10}).Fetch(context.Background(), server.URL)
+		Expect(err).To(MatchError(ContainSubstring("exceeds the limit of 10 bytes")))
+
+		document, err := (&HTTPFetcher{MaxBytes: 11}).Fetch(context.Background(), server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(document).To(HaveLen(11))
+	})
+
+	It("should give up on workloads that do not respond in time", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+
+		start := time.Now()
+		_, err := (&HTTPFetcher{Timeout: 50 * time.Millisecond}).Fetch(context.Background(), server.URL)
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+})
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+
+	importerv1alpha1 "github.com/fortytwoservices/swagger-importer/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// readyPodStatus is the status of a pod that passed its readiness probe
+var readyPodStatus = corev1.PodStatus{
+	Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+}
+
+// application is a workload opted into imports with its Service and API, named after the application
+type application struct {
+	pod     *corev1.Pod
+	service *corev1.Service
+	api     *namespacedapimanagement.API
+}
+
+// newApplication returns a ready pod, a Service on port 8080 and the <name>-v1 API of an application
+func newApplication(name, namespace string) application {
+	return application{
+		pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name + "-7d9f",
+				Namespace: namespace,
+				Labels:    map[string]string{"swaggerimporter": "true", "app": name},
+			},
+			Status: readyPodStatus,
+		},
+		service: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+		},
+		api: &namespacedapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name + "-v1",
+				Namespace: namespace,
+				Labels:    map[string]string{"application": name},
+			},
+		},
+	}
+}
+
+// objects returns the pod, the Service and the API of the application
+func (a application) objects() []client.Object {
+	return []client.Object{a.pod, a.service, a.api}
+}
+
+// newTestReconciler returns a reconciler on a fake client holding the objects, fetching documents with the fetcher
+func newTestReconciler(fetcher FetcherFunc, objects ...client.Object) *SwaggerImportReconciler {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = discoveryv1.AddToScheme(scheme)
+	_ = namespacedapimanagement.AddToScheme(scheme)
+	_ = clusterapimanagement.AddToScheme(scheme)
+	_ = importerv1alpha1.AddToScheme(scheme)
+
+	return &SwaggerImportReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(&importerv1alpha1.SwaggerImport{}).
+			Build(),
+		Scheme:  scheme,
+		Log:     zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+		Fetcher: fetcher,
+	}
+}
+
+// serveDocument returns a fetcher serving the document at every location
+func serveDocument(document string) FetcherFunc {
+	return func(ctx context.Context, url string) ([]byte, error) {
+		return []byte(document), nil
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Azure API Management content formats of imported documents
+const (
+	formatOpenAPIJSON = "openapi+json"
+	formatOpenAPIYAML = "openapi"
+	formatSwaggerJSON = "swagger-json"
+	formatWSDL        = "wsdl"
+	formatWADL        = "wadl-xml"
+)
+
+// graphQLPattern matches the type definitions of a GraphQL schema definition language document
+var graphQLPattern = regexp.MustCompile(`(?m)^\s*(schema|type|interface|enum|input|union|extend\s+type)\b[^:\n]*\{`)
+
+// specVersion holds the version fields identifying a Swagger 2.0 or OpenAPI 3.x document
+type specVersion struct {
+	Swagger any `json:"swagger"`
+	OpenAPI any `json:"openapi"`
+}
+
+// detectContentFormat sniffs the document and returns the matching Azure API Management content format
+// together with the content to import. Swagger 2.0 YAML is converted to JSON since API Management only
+// accepts Swagger 2.0 as swagger-json.
+func detectContentFormat(document string) (string, string, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(document, "\ufeff"))
+
+	switch {
+	case trimmed == "":
+		return "", "", fmt.Errorf("document is empty")
+
+	case strings.HasPrefix(trimmed, "<"):
+		return detectXMLFormat(document)
+
+	case strings.HasPrefix(trimmed, "{"):
+		var version specVersion
+		if err := json.Unmarshal([]byte(trimmed), &version); err != nil {
+			return "", "", fmt.Errorf("document is not valid JSON: %v", err)
+		}
+		switch {
+		case version.isSwagger2():
+			return formatSwaggerJSON, document, nil
+		case version.isOpenAPI3():
+			return formatOpenAPIJSON, document, nil
+		}
+		return "", "", fmt.Errorf("JSON document is neither Swagger 2.0 nor OpenAPI 3.x")
+
+	case graphQLPattern.MatchString(trimmed):
+		return "", "", fmt.Errorf("GraphQL schemas cannot be imported through spec.forProvider.import")
+	}
+
+	var version specVersion
+	if err := yaml.Unmarshal([]byte(trimmed), &version); err != nil {
+		return "", "", fmt.Errorf("document is neither JSON, YAML nor XML: %v", err)
+	}
+	switch {
+	case version.isSwagger2():
+		converted, err := yaml.YAMLToJSON([]byte(trimmed))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to convert Swagger 2.0 YAML to JSON: %v", err)
+		}
+		return formatSwaggerJSON, string(converted), nil
+	case version.isOpenAPI3():
+		return formatOpenAPIYAML, document, nil
+	}
+	return "", "", fmt.Errorf("YAML document is neither Swagger 2.0 nor OpenAPI 3.x")
+}
+
+// detectXMLFormat tells WSDL and WADL documents apart by their root element
+func detectXMLFormat(document string) (string, string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", "", fmt.Errorf("document is not valid XML: %v", err)
+		}
+
+		root, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch root.Name.Local {
+		case "definitions", "description":
+			return formatWSDL, document, nil
+		case "application":
+			return formatWADL, document, nil
+		}
+		return "", "", fmt.Errorf("XML document with root element %q is neither WSDL nor WADL", root.Name.Local)
+	}
+}
+
+func (v specVersion) isSwagger2() bool {
+	version := fmt.Sprint(v.Swagger)
+	return version == "2.0" || version == "2"
+}
+
+func (v specVersion) isOpenAPI3() bool {
+	return v.OpenAPI != nil && strings.HasPrefix(fmt.Sprint(v.OpenAPI), "3")
+}
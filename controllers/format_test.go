@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("detectContentFormat", func() {
+	DescribeTable("should detect the API Management content format",
+		func(document, expectedFormat string) {
+			format, content, err := detectContentFormat(document)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(format).To(Equal(expectedFormat))
+			Expect(content).To(Equal(document))
+		},
+		Entry("Swagger 2.0 JSON", `{"swagger": "2.0", "info": {"title": "Mock API"}}`, formatSwaggerJSON),
+		Entry("OpenAPI 3.0 JSON", `{"openapi": "3.0.1", "info": {"title": "Mock API"}}`, formatOpenAPIJSON),
+		Entry("OpenAPI 3.1 YAML", "openapi: 3.1.0\ninfo:\n  title: Mock API\n", formatOpenAPIYAML),
+		Entry("WSDL", `<?xml version="1.0"?><wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/"></wsdl:definitions>`, formatWSDL),
+		Entry("WADL", `<application xmlns="http://wadl.dev.java.net/2009/02"></application>`, formatWADL),
+	)
+
+	It("should convert Swagger 2.0 YAML to JSON", func() {
+		format, content, err := detectContentFormat("swagger: \"2.0\"\ninfo:\n  title: Mock API\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(format).To(Equal(formatSwaggerJSON))
+		Expect(content).To(MatchJSON(`{"swagger": "2.0", "info": {"title": "Mock API"}}`))
+	})
+
+	It("should reject GraphQL schemas", func() {
+		_, _, err := detectContentFormat("type Query {\n  payments: [Payment]\n}\n")
+		Expect(err).To(MatchError(ContainSubstring("GraphQL")))
+	})
+
+	It("should reject HTML error pages", func() {
+		_, _, err := detectContentFormat("<html><body>Bad Gateway</body></html>")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	importerv1alpha1 "github.com/fortytwoservices/swagger-importer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// allowedNamespacesAnnotation on a cluster scoped API lists the namespaces allowed to import into it, comma separated
+	allowedNamespacesAnnotation = "swaggerimporter/allowed-namespaces"
+
+	// allowedNamespaceSelectorAnnotation on a cluster scoped API selects the namespaces allowed to import into it by label
+	allowedNamespaceSelectorAnnotation = "swaggerimporter/allowed-namespace-selector"
+)
+
+// importPermitted reports whether workloads or SwaggerImports in the namespace from may import into the
+// namespaced API apiName in the namespace to. Imports within a namespace are always permitted, imports
+// across namespaces need a SwaggerImportGrant in the namespace of the API.
+func (r *SwaggerImportReconciler) importPermitted(ctx context.Context, from, apiName, to string) (bool, error) {
+	if from == to {
+		return true, nil
+	}
+
+	var grants importerv1alpha1.SwaggerImportGrantList
+	if err := r.List(ctx, &grants, client.InNamespace(to)); err != nil {
+		return false, err
+	}
+	for _, grant := range grants.Items {
+		if grantAllows(grant.Spec, from, apiName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// grantAllows reports whether a grant allows imports from a namespace into an API
+func grantAllows(spec importerv1alpha1.SwaggerImportGrantSpec, from, apiName string) bool {
+	fromAllowed := false
+	for _, grantFrom := range spec.From {
+		if grantFrom.Namespace == from {
+			fromAllowed = true
+			break
+		}
+	}
+	if !fromAllowed {
+		return false
+	}
+
+	if len(spec.To) == 0 {
+		return true
+	}
+	for _, grantTo := range spec.To {
+		if grantTo.Name == "" || grantTo.Name == apiName {
+			return true
+		}
+	}
+	return false
+}
+
+// notPermittedError returns the error of an import into an API in another namespace without a grant
+func notPermittedError(from, apiName, to string) error {
+	return fmt.Errorf("no SwaggerImportGrant in namespace %s allows imports from namespace %s into API %s", to, from, apiName)
+}
+
+// clusterImportPermitted reports whether workloads or SwaggerImports in the namespace may import into a cluster
+// scoped API. The allowed namespaces are annotated on the API, APIs without annotations are open to all namespaces
+// unless the reconciler restricts cluster scoped APIs.
+func (r *SwaggerImportReconciler) clusterImportPermitted(ctx context.Context, namespace string, api client.Object) (bool, error) {
+	allowedNamespaces, hasNamespaces := api.GetAnnotations()[allowedNamespacesAnnotation]
+	namespaceSelector, hasSelector := api.GetAnnotations()[allowedNamespaceSelectorAnnotation]
+	if !hasNamespaces && !hasSelector {
+		return !r.RestrictClusterAPIs, nil
+	}
+
+	for _, allowed := range strings.Split(allowedNamespaces, ",") {
+		if strings.TrimSpace(allowed) == namespace {
+			return true, nil
+		}
+	}
+
+	// an empty selector selects no namespaces, like an empty list of namespaces
+	if hasSelector && strings.TrimSpace(namespaceSelector) != "" {
+		selector, err := labels.Parse(namespaceSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s annotation on API %s: %w", allowedNamespaceSelectorAnnotation, api.GetName(), err)
+		}
+
+		var ns corev1.Namespace
+		if err := r.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return selector.Matches(labels.Set(ns.Labels)), nil
+	}
+	return false, nil
+}
+
+// clusterNotPermittedError returns the error of an import into a cluster scoped API that does not allow the namespace
+func clusterNotPermittedError(from, apiName string) error {
+	return fmt.Errorf("cluster scoped API %s does not allow imports from namespace %s, see its %s and %s annotations",
+		apiName, from, allowedNamespacesAnnotation, allowedNamespaceSelectorAnnotation)
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+
+	importerv1alpha1 "github.com/fortytwoservices/swagger-importer/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("grantAllows", func() {
+	grant := importerv1alpha1.SwaggerImportGrantSpec{
+		From: []importerv1alpha1.GrantFrom{{Namespace: "team-a"}},
+		To:   []importerv1alpha1.GrantTo{{Name: "payments-v1"}},
+	}
+
+	DescribeTable("should only allow the granted namespaces and APIs",
+		func(spec importerv1alpha1.SwaggerImportGrantSpec, from, apiName string, allowed bool) {
+			Expect(grantAllows(spec, from, apiName)).To(Equal(allowed))
+		},
+		Entry("granted API", grant, "team-a", "payments-v1", true),
+		Entry("other API", grant, "team-a", "orders-v1", false),
+		Entry("other namespace", grant, "team-c", "payments-v1", false),
+		Entry("all APIs", importerv1alpha1.SwaggerImportGrantSpec{From: grant.From}, "team-a", "orders-v1", true),
+	)
+})
+
+var _ = Describe("Namespace scoping", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *SwaggerImportReconciler
+	)
+
+	swaggerJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+
+	api := func(namespace string) *namespacedapimanagement.API {
+		return &namespacedapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "payments-v1",
+				Namespace: namespace,
+				Labels:    map[string]string{"application": "payments"},
+			},
+		}
+	}
+
+	newReconciler := func(objects ...client.Object) {
+		app := newApplication("payments", "team-a")
+		reconciler = newTestReconciler(serveDocument(swaggerJSON), append(objects, app.pod, app.service)...)
+		fakeClient = reconciler.Client
+	}
+
+	imported := func(namespace string) bool {
+		updated := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v1", Namespace: namespace}, updated)).To(Succeed())
+		return updated.Spec.ForProvider.Import != nil
+	}
+
+	reconcileApplication := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "team-a"}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("should only import into APIs of the same namespace by default", func() {
+		newReconciler(api("team-a"), api("team-b"))
+		reconcileApplication()
+
+		Expect(imported("team-a")).To(BeTrue())
+		Expect(imported("team-b")).To(BeFalse())
+	})
+
+	It("should import into APIs of other namespaces that grant it", func() {
+		grant := &importerv1alpha1.SwaggerImportGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-b"},
+			Spec: importerv1alpha1.SwaggerImportGrantSpec{
+				From: []importerv1alpha1.GrantFrom{{Namespace: "team-a"}},
+			},
+		}
+		newReconciler(api("team-b"), grant)
+		reconcileApplication()
+
+		Expect(imported("team-b")).To(BeTrue())
+	})
+
+	It("should report SwaggerImports referencing APIs of other namespaces without a grant", func() {
+		swaggerImport := &importerv1alpha1.SwaggerImport{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "team-a"},
+			Spec: importerv1alpha1.SwaggerImportSpec{
+				Source: importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+				APIRef: importerv1alpha1.APIReference{Name: "payments-v1", Namespace: "team-b"},
+			},
+		}
+		newReconciler(api("team-b"), swaggerImport)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "team-a"}}
+		_, err := (&SwaggerImportResourceReconciler{SwaggerImportReconciler: reconciler}).Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imported("team-b")).To(BeFalse())
+
+		updatedImport := &importerv1alpha1.SwaggerImport{}
+		Expect(fakeClient.Get(ctx, req.NamespacedName, updatedImport)).To(Succeed())
+		condition := meta.FindStatusCondition(updatedImport.Status.Conditions, importerv1alpha1.ConditionReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(importerv1alpha1.ReasonReferenceNotPermitted))
+	})
+})
+
+var _ = Describe("Cluster API access", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *SwaggerImportReconciler
+		recorder   *eventRecorder
+	)
+
+	swaggerJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+
+	clusterAPI := func(annotations map[string]string) *clusterapimanagement.API {
+		return &clusterapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "payments-v1",
+				Labels:      map[string]string{"application": "payments"},
+				Annotations: annotations,
+			},
+		}
+	}
+
+	newReconciler := func(objects ...client.Object) {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "payments"}}}
+		app := newApplication("payments", "team-a")
+
+		recorder = &eventRecorder{}
+		reconciler = newTestReconciler(serveDocument(swaggerJSON), append(objects, namespace, app.pod, app.service)...)
+		reconciler.Recorder = recorder
+		fakeClient = reconciler.Client
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	DescribeTable("should only allow the annotated namespaces",
+		func(annotations map[string]string, restrict, allowed bool) {
+			newReconciler()
+			reconciler.RestrictClusterAPIs = restrict
+
+			permitted, err := reconciler.clusterImportPermitted(ctx, "team-a", clusterAPI(annotations))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(permitted).To(Equal(allowed))
+		},
+		Entry("no annotations", nil, false, true),
+		Entry("no annotations when restricted", nil, true, false),
+		Entry("listed namespace", map[string]string{allowedNamespacesAnnotation: "team-b, team-a"}, true, true),
+		Entry("other namespaces", map[string]string{allowedNamespacesAnnotation: "team-b"}, false, false),
+		Entry("matching namespace labels", map[string]string{allowedNamespaceSelectorAnnotation: "team=payments"}, true, true),
+		Entry("other namespace labels", map[string]string{allowedNamespaceSelectorAnnotation: "team=orders"}, false, false),
+		Entry("empty namespaces", map[string]string{allowedNamespacesAnnotation: ""}, false, false),
+		Entry("empty namespace selector", map[string]string{allowedNamespaceSelectorAnnotation: ""}, false, false),
+	)
+
+	It("should deny imports from other namespaces with an event", func() {
+		newReconciler(clusterAPI(map[string]string{allowedNamespacesAnnotation: "team-b"}))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "team-a"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &clusterapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v1"}, updated)).To(Succeed())
+		Expect(updated.Spec.ForProvider.Import).To(BeNil())
+		Expect(recorder.events).To(ConsistOf(
+			recordedEvent{Regarding: "Pod", Type: corev1.EventTypeWarning, Reason: eventImportDenied},
+			recordedEvent{Regarding: "API", Type: corev1.EventTypeWarning, Reason: eventImportDenied},
+		))
+	})
+})
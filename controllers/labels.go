@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// Label keys used when the reconciler does not set its own
+const (
+	// defaultOptInLabel opts pods into imports with the value true
+	defaultOptInLabel = "swaggerimporter"
+
+	// defaultAppLabel holds the application name of a pod
+	defaultAppLabel = "app"
+
+	// defaultAPILabel holds the application name of an API
+	defaultAPILabel = "application"
+)
+
+func (r *SwaggerImportReconciler) optInLabel() string {
+	if r.OptInLabel == "" {
+		return defaultOptInLabel
+	}
+	return r.OptInLabel
+}
+
+func (r *SwaggerImportReconciler) appLabel() string {
+	if r.AppLabel == "" {
+		return defaultAppLabel
+	}
+	return r.AppLabel
+}
+
+func (r *SwaggerImportReconciler) apiLabel() string {
+	if r.APILabel == "" {
+		return defaultAPILabel
+	}
+	return r.APILabel
+}
+
+// podSelector selects the pods to import, the PodSelector of the reconciler or pods with the opt-in label
+func (r *SwaggerImportReconciler) podSelector() labels.Selector {
+	if r.PodSelector != nil && !r.PodSelector.Empty() {
+		return r.PodSelector
+	}
+	return labels.SelectorFromSet(labels.Set{r.optInLabel(): "true"})
+}
+
+// selectsPod reports whether a pod with the given labels is imported
+func (r *SwaggerImportReconciler) selectsPod(podLabels map[string]string) bool {
+	if _, found := podLabels[r.appLabel()]; !found {
+		return false
+	}
+	return r.podSelector().Matches(labels.Set(podLabels))
+}
+
+// applicationPodSelector selects the imported pods of an application
+func (r *SwaggerImportReconciler) applicationPodSelector(appName string) (labels.Selector, error) {
+	return withLabel(r.podSelector(), r.appLabel(), appName)
+}
+
+// applicationAPISelector selects the APIs of an application, narrowed down by the APISelector of the reconciler
+func (r *SwaggerImportReconciler) applicationAPISelector(appName string) (labels.Selector, error) {
+	selector := r.APISelector
+	if selector == nil {
+		selector = labels.Everything()
+	}
+	return withLabel(selector, r.apiLabel(), appName)
+}
+
+// withLabel adds the requirement that the label key has the given value to a selector
+func withLabel(selector labels.Selector, key, value string) (labels.Selector, error) {
+	requirement, err := labels.NewRequirement(key, selection.Equals, []string{value})
+	if err != nil {
+		return nil, err
+	}
+	return selector.Add(*requirement), nil
+}
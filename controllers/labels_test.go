@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Label configuration", func() {
+	It("should select pods with the opt-in label by default", func() {
+		reconciler := &SwaggerImportReconciler{}
+		Expect(reconciler.selectsPod(map[string]string{"swaggerimporter": "true", "app": "payments"})).To(BeTrue())
+		Expect(reconciler.selectsPod(map[string]string{"swaggerimporter": "false", "app": "payments"})).To(BeFalse())
+		Expect(reconciler.selectsPod(map[string]string{"swaggerimporter": "true"})).To(BeFalse())
+	})
+
+	It("should select pods with the configured label keys and selector", func() {
+		reconciler := &SwaggerImportReconciler{OptInLabel: "swagger.example.com/import", AppLabel: "app.kubernetes.io/name"}
+		Expect(reconciler.selectsPod(map[string]string{"swagger.example.com/import": "true", "app.kubernetes.io/name": "payments"})).To(BeTrue())
+		Expect(reconciler.selectsPod(map[string]string{"swaggerimporter": "true", "app": "payments"})).To(BeFalse())
+
+		selector, err := labels.Parse("app.kubernetes.io/part-of=shop")
+		Expect(err).NotTo(HaveOccurred())
+		reconciler.PodSelector = selector
+		Expect(reconciler.selectsPod(map[string]string{"app.kubernetes.io/part-of": "shop", "app.kubernetes.io/name": "payments"})).To(BeTrue())
+		Expect(reconciler.selectsPod(map[string]string{"swagger.example.com/import": "true", "app.kubernetes.io/name": "payments"})).To(BeFalse())
+	})
+
+	It("should narrow down the APIs of an application with the API selector", func() {
+		selector, err := labels.Parse("environment=production")
+		Expect(err).NotTo(HaveOccurred())
+		reconciler := &SwaggerImportReconciler{APILabel: "app.kubernetes.io/part-of", APISelector: selector}
+
+		apiSelector, err := reconciler.applicationAPISelector("payments")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apiSelector.Matches(labels.Set{"app.kubernetes.io/part-of": "payments", "environment": "production"})).To(BeTrue())
+		Expect(apiSelector.Matches(labels.Set{"app.kubernetes.io/part-of": "payments", "environment": "staging"})).To(BeFalse())
+		Expect(apiSelector.Matches(labels.Set{"application": "payments", "environment": "production"})).To(BeFalse())
+	})
+
+	It("should import applications with the configured labels", func() {
+		ctx := context.Background()
+		swaggerJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+		app := newApplication("payments", "services")
+		app.pod.Labels = map[string]string{"swaggerimporter": "true", "app.kubernetes.io/name": "payments"}
+		app.api.Labels = map[string]string{"app.kubernetes.io/part-of": "payments"}
+
+		reconciler := newTestReconciler(serveDocument(swaggerJSON), app.objects()...)
+		reconciler.AppLabel = "app.kubernetes.io/name"
+		reconciler.APILabel = "app.kubernetes.io/part-of"
+		fakeClient := reconciler.Client
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v1", Namespace: "services"}, updated)).To(Succeed())
+		Expect(updated.Spec.ForProvider.Import).NotTo(BeNil())
+		Expect(*updated.Spec.ForProvider.Import.ContentValue).To(Equal(swaggerJSON))
+	})
+})
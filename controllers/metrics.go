@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Outcomes of fetches and results of import attempts
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+
+	resultApplied = "applied"
+	resultSkipped = "skipped"
+	resultHeld    = "held"
+)
+
+var (
+	fetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "swagger_importer_fetch_duration_seconds",
+		Help:    "Duration of swagger document fetches per application and port.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"app", "port", "outcome"})
+
+	specSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "swagger_importer_spec_size_bytes",
+		Help: "Size of the last fetched swagger document per API.",
+	}, []string{"api", "namespace"})
+
+	specOperations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "swagger_importer_spec_operations",
+		Help: "Number of operations in the last fetched swagger document per API.",
+	}, []string{"api", "namespace"})
+
+	importsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "swagger_importer_imports_total",
+		Help: "Import attempts per API that were applied, skipped because the API is up to date, or held for approval.",
+	}, []string{"api", "namespace", "result", "reason"})
+
+	validationFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "swagger_importer_validation_failures_total",
+		Help: "Fetched swagger documents per API that failed validation.",
+	}, []string{"api", "namespace"})
+
+	consecutiveFailures = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "swagger_importer_consecutive_failures",
+		Help: "Consecutive failed imports per application or SwaggerImport.",
+	}, []string{"kind", "name", "namespace"})
+
+	lastSyncTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "swagger_importer_last_sync_timestamp_seconds",
+		Help: "Unix time of the last successful sync per API, when the fetched document was imported or already up to date.",
+	}, []string{"api", "namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		fetchDuration,
+		specSizeBytes,
+		specOperations,
+		importsTotal,
+		validationFailuresTotal,
+		consecutiveFailures,
+		lastSyncTimestamp,
+	)
+}
+
+// operationCount returns the number of operations of a document
+func operationCount(doc *openapi3.T) int {
+	if doc == nil || doc.Paths == nil {
+		return 0
+	}
+
+	count := 0
+	for _, pathItem := range doc.Paths.Map() {
+		count += len(pathItem.Operations())
+	}
+	return count
+}
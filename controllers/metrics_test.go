@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Import metrics", func() {
+	It("should count operations of a document", func() {
+		doc, err := parseDocument(context.Background(), formatOpenAPIJSON, `{"openapi": "3.0.1", "info": {"title": "Orders", "version": "1.0"},
+  "paths": {"/orders": {"get": {"responses": {"200": {"description": "OK"}}}, "post": {"responses": {"201": {"description": "Created"}}}},
+            "/orders/{id}": {"get": {"responses": {"200": {"description": "OK"}}}}}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(operationCount(doc)).To(Equal(3))
+		Expect(operationCount(nil)).To(Equal(0))
+	})
+
+	It("should record fetches, imports and syncs of an API", func() {
+		ctx := context.Background()
+		swaggerJSON := `{"openapi": "3.0.1", "info": {"title": "Orders", "version": "1.0"},
+  "paths": {"/orders": {"get": {"responses": {"200": {"description": "OK"}}}}}}`
+
+		app := newApplication("orders", "shop")
+		app.service.Annotations = map[string]string{pathAnnotation: "/openapi.json"}
+		reconciler := newTestReconciler(serveDocument(swaggerJSON), app.objects()...)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "orders", Namespace: "shop"}}
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		fetches := &dto.Metric{}
+		Expect(fetchDuration.WithLabelValues("orders", "8080", outcomeSuccess).(prometheus.Histogram).Write(fetches)).To(Succeed())
+		Expect(fetches.GetHistogram().GetSampleCount()).To(Equal(uint64(2)))
+		Expect(testutil.ToFloat64(specSizeBytes.WithLabelValues("orders-v1", "shop"))).To(Equal(float64(len(swaggerJSON))))
+		Expect(testutil.ToFloat64(specOperations.WithLabelValues("orders-v1", "shop"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(importsTotal.WithLabelValues("orders-v1", "shop", resultApplied, string(updateReasonFirstImport)))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(importsTotal.WithLabelValues("orders-v1", "shop", resultSkipped, string(updateReasonUpToDate)))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(lastSyncTimestamp.WithLabelValues("orders-v1", "shop"))).To(BeNumerically(">", 0))
+	})
+})
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxNewVersions bounds the major versions probed beyond the newest API of an application
+	maxNewVersions = 3
+
+	// versionTemplateAnnotation on an API names a ConfigMap holding the manifest of the APIs created for new
+	// major versions. The ConfigMap is looked up in the namespace of the API, or of the application for
+	// cluster scoped APIs. Without it new APIs are copied from the newest API.
+	versionTemplateAnnotation = "swaggerimporter/version-template"
+
+	// versionTemplateKey is the key of the API manifest in the template ConfigMap
+	versionTemplateKey = "api.yaml"
+)
+
+// inheritedAnnotations are the annotations of the template API kept by the APIs of new major versions
+var inheritedAnnotations = []string{allowedNamespacesAnnotation, allowedNamespaceSelectorAnnotation, versionTemplateAnnotation}
+
+// createVersions probes the next major versions beyond the newest API of an application and creates an API
+// for each version the application publishes, until the first version it does not publish. Created APIs are
+// appended to the lists so they are imported in the same reconcile. Namespaced APIs are only created in the
+// namespace of the application.
+func (r *SwaggerImportReconciler) createVersions(ctx context.Context, pod *corev1.Pod, target *endpoint, appName string, apis *namespacedapimanagement.APIList, clusterAPIs *clusterapimanagement.APIList) error {
+	template, major, err := r.newestAPI(ctx, pod.Namespace, apis, clusterAPIs)
+	if err != nil || template == nil {
+		return err
+	}
+
+	source, err := r.applicationSource(ctx, pod, target, appName, "")
+	if err != nil {
+		return err
+	}
+	if source.template == "" {
+		source.template = defaultPathTemplate
+	}
+	if !versionedTemplate(source.template) {
+		// versions can only be probed at locations containing the version
+		return nil
+	}
+
+	for next := major + 1; next <= major+maxNewVersions; next++ {
+		api, err := r.newVersionAPI(ctx, template, pod.Namespace, next)
+		if err != nil {
+			return err
+		}
+		if declaredVersionSetID(api) == nil {
+			// an API outside a version set would collide with the path of the template API
+			r.Log.Info("Not creating APIs for new versions of an API without a version set", "APIName", template.GetName(), "appName", appName)
+			return nil
+		}
+		if source.version, err = r.apiVersion(api); err != nil {
+			return err
+		}
+		document, err := r.fetchFromSource(ctx, source)
+		if err == nil {
+			err = r.publishedVersion(ctx, document, next, template)
+		}
+		if err != nil {
+			r.Log.V(1).Info("Version not published", "appName", appName, "version", source.version, "reason", err.Error())
+			return nil
+		}
+
+		if err := r.Create(ctx, api, client.FieldOwner(fieldManager)); err != nil {
+			if errors.IsAlreadyExists(err) {
+				// e.g. an API of the version without the application label
+				continue
+			}
+			return fmt.Errorf("failed to create API %s: %w", api.GetName(), err)
+		}
+
+		message := fmt.Sprintf("Created API %s for version %s published by application %s", api.GetName(), source.version, appName)
+		r.Log.Info("Created API for new major version", "APIName", api.GetName(), "ApiNamespace", api.GetNamespace(), "Version", source.version)
+		r.recordEvent(ctx, pod, api.GetName(), api.GetNamespace(), corev1.EventTypeNormal, eventVersionCreated, message)
+
+		switch api := api.(type) {
+		case *namespacedapimanagement.API:
+			apis.Items = append(apis.Items, *api)
+		case *clusterapimanagement.API:
+			clusterAPIs.Items = append(clusterAPIs.Items, *api)
+		}
+	}
+	return nil
+}
+
+// publishedVersion checks that a probed document is a valid swagger document of the major version, so
+// workloads ignoring the version in the location, e.g. with a catch-all route, do not get new APIs. A document
+// equivalent to the one imported into the template API is not a new version either.
+func (r *SwaggerImportReconciler) publishedVersion(ctx context.Context, document string, major int, template client.Object) error {
+	contentFormat, content, err := detectContentFormat(document)
+	if err != nil {
+		return err
+	}
+	doc, err := validatedDocument(ctx, contentFormat, content)
+	if err != nil {
+		return err
+	}
+	if doc == nil || doc.Info == nil {
+		return fmt.Errorf("%s document has no version", contentFormat)
+	}
+	if documentMajor, err := versionMajor(doc.Info.Version); err != nil || documentMajor != major {
+		return fmt.Errorf("document has version %s, not major version %d", doc.Info.Version, major)
+	}
+	if imported := importedContent(template); imported != "" &&
+		documentHash(imported, r.CompareIgnoreFields) == documentHash(content, r.CompareIgnoreFields) {
+		return fmt.Errorf("document is equivalent to the document of API %s", template.GetName())
+	}
+	return nil
+}
+
+// newestAPI returns the API with the highest major version the application may create versions of and
+// its major version, nil when there is none
+func (r *SwaggerImportReconciler) newestAPI(ctx context.Context, namespace string, apis *namespacedapimanagement.APIList, clusterAPIs *clusterapimanagement.APIList) (client.Object, int, error) {
+	var candidates []client.Object
+	for i := range apis.Items {
+		if apis.Items[i].Namespace == namespace {
+			candidates = append(candidates, &apis.Items[i])
+		}
+	}
+	for i := range clusterAPIs.Items {
+		permitted, err := r.clusterImportPermitted(ctx, namespace, &clusterAPIs.Items[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		if permitted {
+			candidates = append(candidates, &clusterAPIs.Items[i])
+		}
+	}
+
+	var newest client.Object
+	newestMajor := 0
+	for _, api := range candidates {
+		version, err := r.apiVersion(api)
+		if err != nil {
+			continue
+		}
+		major, err := versionMajor(version)
+		if err != nil {
+			continue // versions that are not numeric, e.g. dates, have no next major version
+		}
+		if newest == nil || major > newestMajor {
+			newest, newestMajor = api, major
+		}
+	}
+	return newest, newestMajor, nil
+}
+
+// newVersionAPI returns a new API for the major version, from the manifest of the template ConfigMap
+// annotated on the template API or a copy of the template API. The new API keeps the labels, the
+// access control annotations and the version set of the template API. It does not write a connection
+// secret, which would be shared with the template API.
+func (r *SwaggerImportReconciler) newVersionAPI(ctx context.Context, template client.Object, namespace string, major int) (client.Object, error) {
+	api := template.DeepCopyObject().(client.Object)
+	annotations := map[string]string{}
+
+	if name := template.GetAnnotations()[versionTemplateAnnotation]; name != "" {
+		if template.GetNamespace() != "" {
+			namespace = template.GetNamespace()
+		}
+		reader := r.APIReader
+		if reader == nil {
+			reader = r.Client
+		}
+		configMap := &corev1.ConfigMap{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, configMap); err != nil {
+			return nil, fmt.Errorf("failed to get version template %s: %w", name, err)
+		}
+		manifest, found := configMap.Data[versionTemplateKey]
+		if !found {
+			return nil, fmt.Errorf("version template %s has no %s key", name, versionTemplateKey)
+		}
+
+		api, _ = apiObject(template.GetName(), template.GetNamespace())
+		if err := yaml.Unmarshal([]byte(manifest), api); err != nil {
+			return nil, fmt.Errorf("invalid API manifest in version template %s: %w", name, err)
+		}
+		if api.GetAnnotations() != nil {
+			annotations = api.GetAnnotations()
+		}
+	}
+
+	// other annotations of the template API, e.g. the external name, belong to the template API only
+	for _, key := range inheritedAnnotations {
+		if value, found := template.GetAnnotations()[key]; found {
+			if _, set := annotations[key]; !set {
+				annotations[key] = value
+			}
+		}
+	}
+
+	labels := api.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range template.GetLabels() {
+		labels[key] = value
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:        versionName(template.GetName(), major),
+		Namespace:   template.GetNamespace(),
+		Labels:      labels,
+		Annotations: annotations,
+	}
+	version := nextVersion(declaredVersion(template), major)
+	versionSetID := declaredVersionSetID(template)
+
+	switch api := api.(type) {
+	case *namespacedapimanagement.API:
+		api.ObjectMeta = meta
+		api.Spec.WriteConnectionSecretToReference = nil
+		api.Spec.ForProvider.Import = nil
+		api.Spec.ForProvider.Version = &version
+		if api.Spec.ForProvider.VersionSetID == nil {
+			api.Spec.ForProvider.VersionSetID = versionSetID
+		}
+		api.Status = namespacedapimanagement.APIStatus{}
+	case *clusterapimanagement.API:
+		api.ObjectMeta = meta
+		api.Spec.WriteConnectionSecretToReference = nil
+		api.Spec.ForProvider.Import = nil
+		api.Spec.ForProvider.Version = &version
+		if api.Spec.ForProvider.VersionSetID == nil {
+			api.Spec.ForProvider.VersionSetID = versionSetID
+		}
+		api.Status = clusterapimanagement.APIStatus{}
+	}
+	return api, nil
+}
+
+// versionName returns the name of the API of a major version, replacing the version after the last "-v"
+// of the template API name
+func versionName(name string, major int) string {
+	if _, err := majorVersion(name); err == nil {
+		name = name[:strings.LastIndex(name, "-v")]
+	}
+	return fmt.Sprintf("%s-v%d", name, major)
+}
+
+// nextVersion returns the version of the API of a major version in the format of the template API version,
+// e.g. v2.1 becomes v3.0. Versions that are not numeric become v<major>.
+func nextVersion(version string, major int) string {
+	if !numericVersion.MatchString(version) {
+		return fmt.Sprintf("v%d", major)
+	}
+
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+	}
+	components := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	components[0] = strconv.Itoa(major)
+	for i := 1; i < len(components); i++ {
+		components[i] = "0"
+	}
+	return prefix + strings.Join(components, ".")
+}
+
+// importedContent returns the document imported into spec.forProvider.import of an API, empty when it has none
+func importedContent(api client.Object) string {
+	var value *string
+	switch api := api.(type) {
+	case *namespacedapimanagement.API:
+		if api.Spec.ForProvider.Import != nil {
+			value = api.Spec.ForProvider.Import.ContentValue
+		}
+	case *clusterapimanagement.API:
+		if api.Spec.ForProvider.Import != nil {
+			value = api.Spec.ForProvider.Import.ContentValue
+		}
+	}
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// declaredVersionSetID returns the version set declared in spec.forProvider.versionSetId of an API
+func declaredVersionSetID(api client.Object) *string {
+	switch api := api.(type) {
+	case *namespacedapimanagement.API:
+		return api.Spec.ForProvider.VersionSetID
+	case *clusterapimanagement.API:
+		return api.Spec.ForProvider.VersionSetID
+	}
+	return nil
+}
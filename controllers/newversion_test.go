@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("New major versions", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *SwaggerImportReconciler
+	)
+
+	// document returns the swagger document of a version, e.g. 3.0 for v3.0
+	document := func(version string) string {
+		return fmt.Sprintf(`{"openapi": "3.0.1", "info": {"title": "Payments", "version": "%s"}, "paths": {}}`, strings.TrimPrefix(version, "v"))
+	}
+
+	api := func(name string, annotations map[string]string) *namespacedapimanagement.API {
+		return &namespacedapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{"application": "payments"},
+				Annotations: annotations,
+			},
+			Spec: namespacedapimanagement.APISpec{ForProvider: namespacedapimanagement.APIParameters{
+				DisplayName:  ptr.To("Payments"),
+				VersionSetID: ptr.To("payments-set"),
+			}},
+		}
+	}
+
+	// newReconciler serves the swagger documents of the given versions
+	newReconciler := func(versions []string, objects ...client.Object) {
+		app := newApplication("payments", "default")
+		reconciler = newTestReconciler(func(ctx context.Context, url string) ([]byte, error) {
+			for _, version := range versions {
+				if strings.Contains(url, "/swagger/"+version+"/") {
+					return []byte(document(version)), nil
+				}
+			}
+			return nil, fmt.Errorf("unexpected status code 404 from %s", url)
+		}, append(objects, app.pod, app.service)...)
+		reconciler.CreateVersions = true
+		fakeClient = reconciler.Client
+	}
+
+	reconcileApplication := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	DescribeTable("should name and version the API of a major version after the template API",
+		func(name, version string, major int, expectedName, expectedVersion string) {
+			Expect(versionName(name, major)).To(Equal(expectedName))
+			Expect(nextVersion(version, major)).To(Equal(expectedVersion))
+		},
+		Entry("major version", "payments-v2", "v2", 3, "payments-v3", "v3"),
+		Entry("minor version", "my-vault-api-v2.1", "v2.1", 3, "my-vault-api-v3", "v3.0"),
+		Entry("without prefix", "payments-v2", "2", 3, "payments-v3", "3"),
+		Entry("generated name", "payments-8fk2x", "", 3, "payments-8fk2x-v3", "v3"),
+		Entry("date version", "payments-v2", "2024-01", 3, "payments-v3", "v3"),
+	)
+
+	It("should create and import APIs for the new major versions the application publishes", func() {
+		newReconciler([]string{"v1.0", "v2.0", "v3.0"}, api("payments-v1", nil), api("payments-v2", nil))
+		reconcileApplication()
+
+		created := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v3", Namespace: "default"}, created)).To(Succeed())
+		Expect(created.Labels).To(HaveKeyWithValue("application", "payments"))
+		Expect(*created.Spec.ForProvider.Version).To(Equal("v3"))
+		Expect(*created.Spec.ForProvider.VersionSetID).To(Equal("payments-set"))
+		Expect(*created.Spec.ForProvider.DisplayName).To(Equal("Payments"))
+		Expect(created.Spec.ForProvider.Import).NotTo(BeNil())
+		Expect(*created.Spec.ForProvider.Import.ContentValue).To(Equal(document("v3.0")))
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v4", Namespace: "default"}, &namespacedapimanagement.API{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should create new versions from the template ConfigMap", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments-template", Namespace: "default"},
+			Data: map[string]string{versionTemplateKey: `
+metadata:
+  labels:
+    team: payments
+spec:
+  forProvider:
+    displayName: Payments next
+`},
+		}
+		newReconciler([]string{"v2.0", "v3.0"},
+			api("payments-v2", map[string]string{versionTemplateAnnotation: "payments-template", "crossplane.io/external-name": "payments-v2"}))
+		// ConfigMaps are not cached, the template is read from the API server
+		reconciler.APIReader = fake.NewClientBuilder().WithScheme(reconciler.Scheme).WithObjects(configMap).Build()
+		reconcileApplication()
+
+		created := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v3", Namespace: "default"}, created)).To(Succeed())
+		Expect(created.Labels).To(HaveKeyWithValue("application", "payments"))
+		Expect(created.Labels).To(HaveKeyWithValue("team", "payments"))
+		Expect(created.Annotations).NotTo(HaveKey("crossplane.io/external-name"))
+		Expect(created.Annotations).To(HaveKeyWithValue(versionTemplateAnnotation, "payments-template"))
+		Expect(*created.Spec.ForProvider.DisplayName).To(Equal("Payments next"))
+		Expect(*created.Spec.ForProvider.VersionSetID).To(Equal("payments-set"))
+	})
+
+	It("should not create APIs for documents of other versions", func() {
+		newReconciler(nil, api("payments-v1", nil))
+		// catch-all route serving the document of the current version at every location
+		reconciler.Fetcher = serveDocument(document("v1.0"))
+		reconcileApplication()
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v2", Namespace: "default"}, &namespacedapimanagement.API{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not create APIs for documents equivalent to the newest API", func() {
+		imported := api("payments-v2", nil)
+		imported.Spec.ForProvider.Import = &namespacedapimanagement.ImportParameters{
+			ContentFormat: ptr.To(formatOpenAPIJSON),
+			ContentValue:  ptr.To(document("v3.0")),
+		}
+		newReconciler([]string{"v3.0"}, imported)
+		reconciler.Fetcher = serveDocument(document("v3.0"))
+		reconcileApplication()
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v3", Namespace: "default"}, &namespacedapimanagement.API{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not probe new versions of date versioned APIs", func() {
+		var fetched []string
+		newReconciler(nil, withSpecVersion(api("payments-2024", nil), "2024-01-01"))
+		reconciler.Fetcher = FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+			fetched = append(fetched, url)
+			return []byte(document("2024-01-01")), nil
+		})
+		reconcileApplication()
+
+		Expect(fetched).To(ConsistOf(ContainSubstring("/swagger/2024-01-01/swagger.json")))
+	})
+
+	It("should not create APIs outside a version set", func() {
+		unversioned := api("payments-v2", nil)
+		unversioned.Spec.ForProvider.VersionSetID = nil
+		newReconciler([]string{"v2.0", "v3.0"}, unversioned)
+		reconcileApplication()
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v3", Namespace: "default"}, &namespacedapimanagement.API{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should keep the namespaces allowed to import into new cluster APIs", func() {
+		clusterAPI := &clusterapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "payments-v2",
+				Labels:      map[string]string{"application": "payments"},
+				Annotations: map[string]string{allowedNamespacesAnnotation: "default"},
+			},
+			Spec: clusterapimanagement.APISpec{ForProvider: clusterapimanagement.APIParameters{VersionSetID: ptr.To("payments-set")}},
+		}
+		Expect(json.Unmarshal([]byte(`{"spec": {"writeConnectionSecretToRef": {"name": "payments-v2", "namespace": "default"}}}`), clusterAPI)).To(Succeed())
+		Expect(clusterAPI.Spec.WriteConnectionSecretToReference).NotTo(BeNil())
+		newReconciler([]string{"v2.0", "v3.0"}, clusterAPI)
+		reconciler.RestrictClusterAPIs = true
+		reconcileApplication()
+
+		created := &clusterapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v3"}, created)).To(Succeed())
+		Expect(created.Annotations).To(HaveKeyWithValue(allowedNamespacesAnnotation, "default"))
+		Expect(created.Spec.WriteConnectionSecretToReference).To(BeNil())
+		Expect(created.Spec.ForProvider.Import).NotTo(BeNil())
+	})
+
+	It("should not create APIs when disabled", func() {
+		newReconciler([]string{"v2.0", "v3.0"}, api("payments-v2", nil))
+		reconciler.CreateVersions = false
+		reconcileApplication()
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v3", Namespace: "default"}, &namespacedapimanagement.API{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// defaultMaxFailureBackoff is the ceiling of the requeue interval of failing applications
+	// when the reconciler does not set one
+	defaultMaxFailureBackoff = 30 * time.Minute
+
+	// failureBackoffJitter spreads the requeues of failing applications
+	failureBackoffJitter = 0.1
+)
+
+// Kinds of importers whose consecutive failures are tracked, SwaggerImports sharing the Service of an
+// application fail independently of it and of each other
+const (
+	failureKindApplication   = "app"
+	failureKindSwaggerImport = "swaggerimport"
+)
+
+// defaultFetchRetry retries transient fetch failures after about 0.5s, 1s and 2s
+var defaultFetchRetry = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.2,
+	Steps:    4,
+	Cap:      5 * time.Second,
+}
+
+// isTransient reports whether a fetch failed for a reason that is likely to go away on its own,
+// like a workload that is still starting or temporarily overloaded. Timeouts are not retried, a
+// hanging workload would otherwise hold the worker for several fetch timeouts.
+func isTransient(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.statusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// isUnreachable reports whether a fetch failed without a response, e.g. because the connection was refused
+// or timed out. Other locations on the same host and port are not probed after such a failure.
+func isUnreachable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// fetchWithRetry fetches the document at url and retries transient failures with jittered exponential backoff
+func (r *SwaggerImportReconciler) fetchWithRetry(ctx context.Context, url string) ([]byte, error) {
+	backoff := r.FetchRetry
+	if backoff.Steps <= 0 {
+		backoff = defaultFetchRetry
+	}
+
+	for {
+		document, err := r.Fetcher.Fetch(ctx, url)
+		if err == nil || !isTransient(err) || ctx.Err() != nil {
+			return document, err
+		}
+
+		// every attempt takes a step, the last one is not followed by a delay
+		if backoff.Steps <= 1 {
+			return nil, err
+		}
+		delay := backoff.Step()
+		r.Log.V(1).Info("Retrying transient fetch failure", "URL", url, "Delay", delay, "Reason", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// trackFailures records the outcome of an import of an application or SwaggerImport, identified by its kind,
+// namespace and name, and returns its number of consecutive failures
+func (r *SwaggerImportReconciler) trackFailures(kind, namespace, name string, failed bool) int {
+	key := failureKey(kind, namespace, name)
+	failures := 0
+	if failed {
+		previous, _ := r.failures.Load(key)
+		if previous != nil {
+			failures = previous.(int)
+		}
+		failures++
+		r.failures.Store(key, failures)
+	} else {
+		r.failures.Delete(key)
+	}
+
+	consecutiveFailures.WithLabelValues(kind, name, namespace).Set(float64(failures))
+	return failures
+}
+
+// forgetFailures removes the consecutive failures and their metric of a deleted SwaggerImport
+func (r *SwaggerImportReconciler) forgetFailures(kind, namespace, name string) {
+	r.failures.Delete(failureKey(kind, namespace, name))
+	consecutiveFailures.DeleteLabelValues(kind, name, namespace)
+}
+
+func failureKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// failureBackoff returns the requeue interval of an application after the given number of consecutive failures.
+// The interval doubles with every failure up to the ceiling of the reconciler.
+func (r *SwaggerImportReconciler) failureBackoff(interval time.Duration, failures int) time.Duration {
+	if failures <= 1 {
+		return interval
+	}
+
+	ceiling := r.MaxFailureBackoff
+	if ceiling <= 0 {
+		ceiling = defaultMaxFailureBackoff
+	}
+	if interval >= ceiling {
+		return interval
+	}
+
+	backoff := float64(interval) * math.Pow(2, float64(failures-1))
+	if backoff >= float64(ceiling) {
+		return ceiling
+	}
+	return min(wait.Jitter(time.Duration(backoff), failureBackoffJitter), ceiling)
+}
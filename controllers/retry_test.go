@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("isTransient", func() {
+	DescribeTable("should only retry failures that go away on their own",
+		func(err error, transient bool) {
+			Expect(isTransient(err)).To(Equal(transient))
+		},
+		Entry("connection refused", fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED), true),
+		Entry("timeout", fmt.Errorf("get: %w", context.DeadlineExceeded), false),
+		Entry("service unavailable", &statusError{url: "http://payments", statusCode: http.StatusServiceUnavailable}, true),
+		Entry("too many requests", &statusError{url: "http://payments", statusCode: http.StatusTooManyRequests}, true),
+		Entry("not found", &statusError{url: "http://payments", statusCode: http.StatusNotFound}, false),
+		Entry("other errors", errors.New("unsupported protocol scheme"), false),
+	)
+})
+
+var _ = Describe("fetchWithRetry", func() {
+	var attempts int
+
+	newReconciler := func(failures int, err error) *SwaggerImportReconciler {
+		attempts = 0
+		return &SwaggerImportReconciler{
+			Log:        zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			FetchRetry: wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3},
+			Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				attempts++
+				if attempts <= failures {
+					return nil, err
+				}
+				return []byte(`{"openapi": "3.0.1"}`), nil
+			}),
+		}
+	}
+
+	It("should retry transient failures", func() {
+		reconciler := newReconciler(2, &statusError{url: "http://payments", statusCode: http.StatusServiceUnavailable})
+
+		document, err := reconciler.fetchWithRetry(context.Background(), "http://payments")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(document)).To(Equal(`{"openapi": "3.0.1"}`))
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("should give up after the last step", func() {
+		reconciler := newReconciler(5, fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED))
+
+		_, err := reconciler.fetchWithRetry(context.Background(), "http://payments")
+		Expect(errors.Is(err, syscall.ECONNREFUSED)).To(BeTrue())
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("should not retry other failures", func() {
+		reconciler := newReconciler(5, &statusError{url: "http://payments", statusCode: http.StatusNotFound})
+
+		_, err := reconciler.fetchWithRetry(context.Background(), "http://payments")
+		Expect(err).To(HaveOccurred())
+		Expect(attempts).To(Equal(1))
+	})
+
+	DescribeTable("should stop probing locations on ports of unreachable or overloaded workloads",
+		func(err error, expectedAttempts int) {
+			reconciler := newReconciler(100, err)
+			source := swaggerSource{namespace: "services", service: "payments", ports: []int32{8080, 8081}, version: "v1.0"}
+
+			_, fetchErr := reconciler.fetchFromSource(context.Background(), source)
+			Expect(fetchErr).To(HaveOccurred())
+			Expect(attempts).To(Equal(expectedAttempts))
+		},
+		Entry("timeout", fmt.Errorf("get: %w", context.DeadlineExceeded), 2),
+		Entry("connection refused", fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED), 6),
+		Entry("service unavailable", &statusError{url: "http://payments", statusCode: http.StatusServiceUnavailable}, 6),
+		Entry("too many requests", &statusError{url: "http://payments", statusCode: http.StatusTooManyRequests}, 6),
+		Entry("not found", &statusError{url: "http://payments", statusCode: http.StatusNotFound}, 2*len(wellKnownPathTemplates)),
+	)
+})
+
+var _ = Describe("failureBackoff", func() {
+	reconciler := &SwaggerImportReconciler{MaxFailureBackoff: 10 * time.Minute}
+
+	It("should back off progressively up to the ceiling", func() {
+		Expect(reconciler.failureBackoff(time.Minute, 0)).To(Equal(time.Minute))
+		Expect(reconciler.failureBackoff(time.Minute, 1)).To(Equal(time.Minute))
+		Expect(reconciler.failureBackoff(time.Minute, 2)).To(BeNumerically("~", 2*time.Minute, 12*time.Second))
+		Expect(reconciler.failureBackoff(time.Minute, 3)).To(BeNumerically("~", 4*time.Minute, 24*time.Second))
+		Expect(reconciler.failureBackoff(time.Minute, 5)).To(Equal(10 * time.Minute))
+		Expect(reconciler.failureBackoff(time.Minute, 100)).To(Equal(10 * time.Minute))
+	})
+
+	It("should not shorten intervals above the ceiling", func() {
+		Expect(reconciler.failureBackoff(time.Hour, 3)).To(Equal(time.Hour))
+	})
+
+	It("should count consecutive failures per application", func() {
+		tracker := &SwaggerImportReconciler{}
+		Expect(tracker.trackFailures(failureKindApplication, "services", "payments", true)).To(Equal(1))
+		Expect(tracker.trackFailures(failureKindApplication, "services", "payments", true)).To(Equal(2))
+		Expect(tracker.trackFailures(failureKindApplication, "services", "orders", true)).To(Equal(1))
+		Expect(tracker.trackFailures(failureKindApplication, "services", "payments", false)).To(Equal(0))
+		Expect(tracker.trackFailures(failureKindApplication, "services", "payments", true)).To(Equal(1))
+	})
+
+	It("should count consecutive failures of SwaggerImports apart from their application", func() {
+		tracker := &SwaggerImportReconciler{}
+		Expect(tracker.trackFailures(failureKindSwaggerImport, "services", "payments-v2", true)).To(Equal(1))
+		Expect(tracker.trackFailures(failureKindSwaggerImport, "services", "payments-v1", false)).To(Equal(0))
+		Expect(tracker.trackFailures(failureKindApplication, "services", "payments", false)).To(Equal(0))
+		Expect(tracker.trackFailures(failureKindSwaggerImport, "services", "payments-v2", true)).To(Equal(2))
+		Expect(testutil.ToFloat64(consecutiveFailures.WithLabelValues(failureKindSwaggerImport, "payments-v2", "services"))).To(Equal(2.0))
+	})
+})
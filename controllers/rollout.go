@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// revisionAnnotation holds the rollout revision of a Deployment and of its ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// rolloutDivergenceError is returned when the ready replicas of an application serve different documents
+type rolloutDivergenceError struct {
+	service   string
+	endpoints int
+	documents int
+}
+
+func (e *rolloutDivergenceError) Error() string {
+	return fmt.Sprintf("%d ready endpoints of service %s serve %d different swagger documents, waiting for the rollout to complete",
+		e.endpoints, e.service, e.documents)
+}
+
+// isRolloutDivergence reports whether err was caused by replicas serving different documents
+func isRolloutDivergence(err error) bool {
+	var divergenceErr *rolloutDivergenceError
+	return errors.As(err, &divergenceErr)
+}
+
+// fetchDocument fetches the swagger document of the source. With consistent rollouts the document is
+// fetched from every ready endpoint of the Service instead of through the Service.
+func (r *SwaggerImportReconciler) fetchDocument(ctx context.Context, source swaggerSource) (string, error) {
+	if !r.ConsistentRollouts || source.host != "" {
+		return r.fetchFromSource(ctx, source)
+	}
+	return r.fetchConsistent(ctx, source)
+}
+
+// fetchConsistent fetches the document from each ready endpoint of the Service and returns it when all
+// endpoints agree. Otherwise it returns the document of the current ReplicaSet once the rollout of its
+// Deployment is complete, and a rolloutDivergenceError while the rollout is in progress.
+func (r *SwaggerImportReconciler) fetchConsistent(ctx context.Context, source swaggerSource) (string, error) {
+	endpoints, err := r.readyEndpoints(ctx, source.namespace, source.service)
+	if err != nil {
+		return "", err
+	}
+	if len(endpoints) == 0 {
+		// services without endpoint slices, e.g. external names, are fetched through the service
+		return r.fetchFromSource(ctx, source)
+	}
+
+	documents := map[string]string{}
+	hashes := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		candidate := source
+		candidate.host = ep.address
+		candidate.ports = ep.ports
+
+		document, err := r.fetchFromSource(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		hashes[i] = documentHash(document, r.CompareIgnoreFields)
+		documents[hashes[i]] = document
+	}
+
+	if len(documents) == 1 {
+		return documents[hashes[0]], nil
+	}
+
+	for i, ep := range endpoints {
+		r.Log.Info("Swagger document diverges between replicas", "Service", source.service,
+			"Pod", ep.pod, "Address", ep.address, "DocumentHash", hashes[i])
+	}
+
+	current, err := r.rolloutPods(ctx, source.namespace, endpoints)
+	if err != nil {
+		return "", err
+	}
+	if current != nil {
+		currentDocuments := map[string]bool{}
+		for i, ep := range endpoints {
+			if current[ep.pod] {
+				currentDocuments[hashes[i]] = true
+			}
+		}
+		if len(currentDocuments) == 1 {
+			for hash := range currentDocuments {
+				r.Log.Info("Rollout complete, importing the document of the current replicas", "Service", source.service, "DocumentHash", hash)
+				return documents[hash], nil
+			}
+		}
+	}
+
+	return "", &rolloutDivergenceError{service: source.service, endpoints: len(endpoints), documents: len(documents)}
+}
+
+// rolloutPods returns the pods of the endpoints that belong to the current ReplicaSet of their Deployment
+// once its rollout is complete. It returns nil while the rollout is in progress or when the endpoints do
+// not belong to a single Deployment.
+func (r *SwaggerImportReconciler) rolloutPods(ctx context.Context, namespace string, endpoints []endpoint) (map[string]bool, error) {
+	podReplicaSets := map[string]string{}
+	for _, ep := range endpoints {
+		if ep.pod == "" {
+			return nil, nil
+		}
+
+		var pod corev1.Pod
+		if err := r.Get(ctx, client.ObjectKey{Name: ep.pod, Namespace: namespace}, &pod); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		owner := metav1.GetControllerOf(&pod)
+		if owner == nil || owner.Kind != "ReplicaSet" {
+			return nil, nil
+		}
+		podReplicaSets[pod.Name] = owner.Name
+	}
+
+	var deployment string
+	revisions := map[string]string{}
+	for _, name := range podReplicaSets {
+		if _, found := revisions[name]; found {
+			continue
+		}
+
+		var replicaSet appsv1.ReplicaSet
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &replicaSet); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		owner := metav1.GetControllerOf(&replicaSet)
+		if owner == nil || owner.Kind != "Deployment" || (deployment != "" && owner.Name != deployment) {
+			return nil, nil
+		}
+		deployment = owner.Name
+		revisions[name] = replicaSet.Annotations[revisionAnnotation]
+	}
+
+	var rollout appsv1.Deployment
+	if err := r.Get(ctx, client.ObjectKey{Name: deployment, Namespace: namespace}, &rollout); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if !rolloutComplete(&rollout) {
+		r.Log.Info("Waiting for the rollout to complete", "Deployment", deployment)
+		return nil, nil
+	}
+
+	current := map[string]bool{}
+	for pod, replicaSet := range podReplicaSets {
+		if revisions[replicaSet] == rollout.Annotations[revisionAnnotation] {
+			current[pod] = true
+		}
+	}
+	return current, nil
+}
+
+// rolloutComplete reports whether all replicas of a Deployment run its latest revision and are available
+func rolloutComplete(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	return status.ObservedGeneration >= deployment.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.Replicas == replicas &&
+		status.AvailableReplicas == replicas
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Consistent rollouts", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *SwaggerImportReconciler
+		documents  map[string]string
+		deployment *appsv1.Deployment
+	)
+
+	oldJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`
+	newJSON := `{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.1"},
+  "paths": {"/payments": {"get": {"responses": {"200": {"description": "OK"}}}}}}`
+
+	source := swaggerSource{namespace: "services", service: "payments", template: "/openapi.json"}
+
+	replicaSet := func(name, revision string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "services",
+				Annotations:     map[string]string{revisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "payments", Controller: ptr.To(true)}},
+			},
+		}
+	}
+
+	replica := func(name, replicaSet string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "services",
+				Labels:          map[string]string{"swaggerimporter": "true", "app": "payments"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: replicaSet, Controller: ptr.To(true)}},
+			},
+			Status: readyPodStatus,
+		}
+	}
+
+	podEndpoint := func(address, pod string) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "services"},
+		}
+	}
+
+	newReconciler := func() {
+		endpointSlice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "payments-a",
+				Namespace: "services",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "payments"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       []discoveryv1.EndpointPort{{Port: ptr.To[int32](8080)}},
+			Endpoints:   []discoveryv1.Endpoint{podEndpoint("10.0.0.1", "payments-old"), podEndpoint("10.0.0.2", "payments-new")},
+		}
+		// the replicas take the place of the pod of the application
+		app := newApplication("payments", "services")
+		app.service.Spec.Ports = []corev1.ServicePort{{Port: 80}}
+
+		reconciler = newTestReconciler(func(ctx context.Context, rawURL string) ([]byte, error) {
+			u, err := url.Parse(rawURL)
+			Expect(err).NotTo(HaveOccurred())
+			return []byte(documents[u.Hostname()]), nil
+		},
+			deployment, replicaSet("payments-old", "1"), replicaSet("payments-new", "2"),
+			replica("payments-old", "payments-old"), replica("payments-new", "payments-new"),
+			endpointSlice, app.service, app.api,
+		)
+		reconciler.ConsistentRollouts = true
+		fakeClient = reconciler.Client
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		documents = map[string]string{"10.0.0.1": oldJSON, "10.0.0.2": newJSON}
+		deployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "payments",
+				Namespace:   "services",
+				Annotations: map[string]string{revisionAnnotation: "2"},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+			// the rollout replaced one of two replicas
+			Status: appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2},
+		}
+	})
+
+	It("should import when all replicas serve the same document", func() {
+		documents["10.0.0.1"] = newJSON
+		newReconciler()
+
+		document, err := reconciler.fetchDocument(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(document).To(Equal(newJSON))
+	})
+
+	It("should hold the import while replicas diverge during a rollout", func() {
+		newReconciler()
+
+		_, err := reconciler.fetchDocument(ctx, source)
+		Expect(isRolloutDivergence(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("2 ready endpoints of service payments serve 2 different swagger documents")))
+	})
+
+	It("should import the document of the current replicas once the rollout is complete", func() {
+		// the old replica is still listed as ready while it shuts down
+		deployment.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+		newReconciler()
+
+		document, err := reconciler.fetchDocument(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(document).To(Equal(newJSON))
+	})
+
+	It("should not count a held import as a failure", func() {
+		newReconciler()
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(1 * time.Minute))
+
+		api := &namespacedapimanagement.API{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v1", Namespace: "services"}, api)).To(Succeed())
+		Expect(api.Spec.ForProvider.Import).To(BeNil())
+	})
+})
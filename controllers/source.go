@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// pathAnnotation overrides where the swagger document is served on a pod or service.
+	// The value is either a path or a full URL template, e.g. "/v3/api-docs" or
+	// "{scheme}://payments-docs:{port}/openapi/{version}.json".
+	pathAnnotation = "swaggerimporter/path"
+
+	// schemeAnnotation overrides the scheme used to fetch the swagger document
+	schemeAnnotation = "swaggerimporter/scheme"
+
+	// defaultPathTemplate is the Swashbuckle default location of the swagger document
+	defaultPathTemplate = "/swagger/{version}/swagger.json"
+
+	defaultScheme = "http"
+)
+
+// swaggerSource describes where a swagger document is served from
+type swaggerSource struct {
+	namespace string
+	service   string
+	ports     []int32
+	version   string
+
+	// template is a path or URL template supporting the {scheme}, {port}, {version}, {major},
+	// {service} and {namespace} placeholders
+	template string
+	scheme   string
+
+	// host replaces the in-cluster DNS name of the service, e.g. with the address of a pod
+	host string
+}
+
+// url returns the URL of the swagger document on the given port. Path templates are
+// resolved against the host of the source or the in-cluster DNS name of the service.
+func (s swaggerSource) url(port int32) string {
+	template := s.template
+	if template == "" {
+		template = defaultPathTemplate
+	}
+	scheme := s.scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+
+	expanded := strings.NewReplacer(
+		"{scheme}", scheme,
+		"{port}", strconv.Itoa(int(port)),
+		"{version}", s.version,
+		"{major}", renderVersion("{major}", s.version),
+		"{service}", s.service,
+		"{namespace}", s.namespace,
+	).Replace(template)
+
+	if strings.Contains(expanded, "://") {
+		return expanded
+	}
+	if !strings.HasPrefix(expanded, "/") {
+		expanded = "/" + expanded
+	}
+
+	host := s.host
+	if host == "" {
+		host = fmt.Sprintf("%s.%s.svc.cluster.local", s.service, s.namespace)
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, strconv.Itoa(int(port))), expanded)
+}
+
+// swaggerLocation returns the path template and scheme annotated on the pod or the
+// service of the application. Pod annotations take precedence over the service.
+// Empty values fall back to the defaults of swaggerSource.
+func (r *SwaggerImportReconciler) swaggerLocation(ctx context.Context, namespace, appName string, pod *corev1.Pod) (string, string) {
+	objects := []client.Object{}
+	if pod != nil {
+		objects = append(objects, pod)
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, client.ObjectKey{Name: appName, Namespace: namespace}, svc); err == nil {
+		objects = append(objects, svc)
+	}
+
+	var template, scheme string
+	for _, obj := range objects {
+		annotations := obj.GetAnnotations()
+		if template == "" {
+			template = annotations[pathAnnotation]
+		}
+		if scheme == "" {
+			scheme = annotations[schemeAnnotation]
+		}
+	}
+
+	return template, scheme
+}
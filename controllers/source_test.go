@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("swaggerSource", func() {
+	source := swaggerSource{
+		namespace: "services",
+		service:   "payments",
+		version:   "v2.0",
+	}
+
+	It("should default to the Swashbuckle path", func() {
+		Expect(source.url(8080)).To(Equal("http://payments.services.svc.cluster.local:8080/swagger/v2.0/swagger.json"))
+	})
+
+	It("should expand placeholders in a path template", func() {
+		s := source
+		s.template = "api-docs/{version}"
+		s.scheme = "https"
+		Expect(s.url(8443)).To(Equal("https://payments.services.svc.cluster.local:8443/api-docs/v2.0"))
+	})
+
+	It("should resolve a path template against the host", func() {
+		s := source
+		s.host = "10.244.1.17"
+		Expect(s.url(8080)).To(Equal("http://10.244.1.17:8080/swagger/v2.0/swagger.json"))
+
+		s.host = "fd00::17"
+		Expect(s.url(8080)).To(Equal("http://[fd00::17]:8080/swagger/v2.0/swagger.json"))
+	})
+
+	It("should render the major version of the source", func() {
+		s := source
+		s.template = "/swagger/v{major}/swagger.json"
+		Expect(s.url(8080)).To(Equal("http://payments.services.svc.cluster.local:8080/swagger/v2/swagger.json"))
+	})
+
+	It("should use a full URL template as is", func() {
+		s := source
+		s.template = "{scheme}://{service}-docs.{namespace}:{port}/openapi.json"
+		Expect(s.url(80)).To(Equal("http://payments-docs.services:80/openapi.json"))
+	})
+})
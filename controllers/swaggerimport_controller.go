@@ -3,70 +3,144 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
 	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// SwaggerImportReconciler reconciles a SwaggerImport object
+// fieldManager is the manager recorded for the fields written by the importer
+const fieldManager = "swagger-importer"
+
+// SwaggerImportReconciler imports swagger documents from pods labelled swaggerimporter=true, or the labels configured on it
 type SwaggerImportReconciler struct {
 	client.Client
 	Scheme  *runtime.Scheme
 	Log     logr.Logger
-	HTTPGet func(url string) (*http.Response, error)
+	Fetcher Fetcher
+
+	// APIReader reads objects that are not cached, like the ConfigMaps of version templates, the Client when nil.
+	// A cached read would start an informer on all ConfigMaps of the cluster.
+	APIReader client.Reader
+
+	// CompareIgnoreFields are dotted field paths, e.g. info.version, ignored when comparing the
+	// imported document with the fetched one
+	CompareIgnoreFields []string
+
+	// HoldBreakingChanges holds documents with breaking changes within the major version of an API
+	// until they are approved with the approved hash annotation
+	HoldBreakingChanges bool
+
+	// Recorder records an event for every import attempt on its trigger and on the API
+	Recorder events.EventRecorder
+
+	// FetchRetry is the backoff between retries of transient fetch failures, defaultFetchRetry when it has no steps
+	FetchRetry wait.Backoff
+
+	// MaxFailureBackoff is the ceiling of the requeue interval of persistently failing applications
+	MaxFailureBackoff time.Duration
+
+	// OptInLabel, AppLabel and APILabel are the keys of the label opting pods into imports with the value true,
+	// of the application name of pods and of the application name of APIs. They default to swaggerimporter,
+	// app and application.
+	OptInLabel string
+	AppLabel   string
+	APILabel   string
+
+	// PodSelector selects the pods to import instead of the opt-in label
+	PodSelector labels.Selector
+
+	// APISelector narrows down the APIs matched by the application label
+	APISelector labels.Selector
+
+	// RestrictClusterAPIs denies imports into cluster scoped APIs that do not annotate the namespaces allowed to import
+	RestrictClusterAPIs bool
+
+	// FetchFromEndpoints fetches from the ready endpoint of the pod that triggered the reconcile
+	// instead of the Service, so the document matches the version that was just rolled out
+	FetchFromEndpoints bool
+
+	// ConsistentRollouts fetches from every ready endpoint of the Service and only imports when they serve the
+	// same document, or the document of the current ReplicaSet once the rollout of its Deployment is complete
+	ConsistentRollouts bool
+
+	// CreateVersions creates an API for each new major version an application publishes beyond its newest API
+	CreateVersions bool
+
+	// Versions resolves the version of the swagger document of an API, from its name when nil
+	Versions VersionResolver
+
+	// failures counts the consecutive failed imports per application and SwaggerImport
+	failures sync.Map
+
+	// discoveredTemplates remembers the well-known location that served the swagger document per application
+	discoveredTemplates sync.Map
+
+	// claimedLocations remembers the version that took its document from a well-known location without the version
+	claimedLocations sync.Map
 }
 
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
-//+kubebuilder:rbac:groups=apimanagement.azure.upbound.io,resources=apis,verbs=get;list;watch;update;patch
-//+kubebuilder:rbac:groups=apimanagement.azure.m.upbound.io,resources=apis,verbs=get;list;watch;update;patch
-
-// Reconcile function to reconcile SwaggerImport
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments;replicasets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+//+kubebuilder:rbac:groups=apimanagement.azure.upbound.io,resources=apis,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=apimanagement.azure.m.upbound.io,resources=apis,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=importer.swagger-importer.com,resources=swaggerimportgrants,verbs=get;list;watch
+
+// Reconcile imports the swagger document of an application. Requests are keyed by the namespace and
+// app label of the labelled pods, so pod churn of an application results in a single reconcile.
 func (r *SwaggerImportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := r.Log.WithValues("swaggerimport", req.NamespacedName)
+	log := r.Log.WithValues("application", req.NamespacedName)
+	appName := req.Name
 
-	// fetch the pod that triggered the reconcile
-	var pod corev1.Pod
-	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
-		if errors.IsNotFound(err) {
-			// pod does not exist anymore
-			log.Info("Pod not found, will not requeue", "name", req.NamespacedName)
-			return ctrl.Result{}, nil // no requeue
-		}
-		log.Error(err, "Failed to get pod, requeuing")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err // requeue still for other errors
+	// pick the pod serving the application, the pods becoming ready trigger another reconcile
+	pod, err := r.applicationPod(ctx, req.Namespace, appName)
+	if err != nil {
+		log.Error(err, "Failed to list pods, requeuing")
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
 	}
-
-	// extract the 'app' label from the pod or skip
-	appName, found := pod.Labels["app"]
-	if !found {
-		log.Info("Pod does not have 'app' label", "podName", pod.Name)
+	if pod == nil {
+		log.V(1).Info("Application has no ready pods, skipping", "appName", appName)
 		return ctrl.Result{}, nil
 	}
 
-	apiLabelSelector := client.MatchingLabels{"application": appName}
+	apiSelector, err := r.applicationAPISelector(appName)
+	if err != nil {
+		log.Error(err, "Invalid application name, will not requeue", "appName", appName)
+		return ctrl.Result{}, nil
+	}
+	apiLabelSelector := client.MatchingLabelsSelector{Selector: apiSelector}
 
-	// fetch API resources that match the extracted 'app' label
+	// fetch API resources that match the application label
 	var apis namespacedapimanagement.APIList
-	if err := r.List(ctx, &apis, client.MatchingLabels(apiLabelSelector)); err != nil {
+	if err := r.List(ctx, &apis, apiLabelSelector); err != nil {
 		log.Error(err, "Failed to list API resources", "appName", appName)
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
 	}
 
 	// fetch clustered api resources as well
 	var clusterAPIs clusterapimanagement.APIList
-	if err := r.List(ctx, &clusterAPIs, client.MatchingLabels(apiLabelSelector)); err != nil {
+	if err := r.List(ctx, &clusterAPIs, apiLabelSelector); err != nil {
 		log.Error(err, "Failed to list clustered API resources", "appName", appName)
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
 	}
@@ -75,17 +149,60 @@ func (r *SwaggerImportReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 	}
 
+	// resolve the endpoint of the pod, the endpoint slices may lag behind the readiness of the pod
+	var target *endpoint
+	if r.FetchFromEndpoints {
+		target, err = r.podEndpoint(ctx, pod, appName)
+		if err != nil {
+			log.Error(err, "Failed to list endpoint slices", "appName", appName)
+			return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
+		}
+		if target == nil {
+			log.Info("Pod is not a ready endpoint of the service yet", "podName", pod.Name, "appName", appName)
+			return ctrl.Result{RequeueAfter: endpointPollInterval}, nil
+		}
+	}
+
+	// create APIs for the new major versions the application publishes, they are imported below
+	failed := false
+	if r.CreateVersions {
+		if err := r.createVersions(ctx, pod, target, appName, &apis, &clusterAPIs); err != nil {
+			log.Error(err, "Failed to create APIs for new versions", "appName", appName)
+			failed = true
+		}
+	}
+
 	// handle each version
 	for _, api := range apis.Items {
 		log.Info("Processing matching API", "API Name", api.Name, "Label Matched", appName)
-		version, err := parseVersion(api.Name)
+		version, err := r.apiVersion(&api)
 		if err != nil {
-			log.Error(err, "Failed to parse version from API name", "apiName", api.Name)
+			log.Error(err, "Failed to resolve the version of API", "apiName", api.Name)
 			continue // skip APIs with invalid name format
 		}
-		err = r.fetchAndSaveSwagger(ctx, pod.Namespace, api.Name, api.Namespace, appName, version)
+		// namespaced APIs of other namespaces must grant imports from the namespace of the application
+		permitted, err := r.importPermitted(ctx, req.Namespace, api.Name, api.Namespace)
+		if err != nil {
+			log.Error(err, "Failed to list SwaggerImportGrants", "apiName", api.Name, "apiNamespace", api.Namespace)
+			failed = true
+			continue
+		}
+		if !permitted {
+			log.Info("Skipping API in another namespace", "apiName", api.Name, "reason", notPermittedError(req.Namespace, api.Name, api.Namespace).Error())
+			continue
+		}
+		err = r.fetchAndSaveSwagger(ctx, pod, target, api.Name, api.Namespace, appName, version)
+		if isPendingApproval(err) {
+			log.Info("Swagger held for approval", "apiName", api.Name, "reason", err.Error())
+			continue
+		}
+		if isRolloutDivergence(err) {
+			log.Info("Swagger held until the rollout is complete", "apiName", api.Name, "reason", err.Error())
+			continue
+		}
 		if err != nil {
 			log.Error(err, "Failed to fetch Swagger JSON", "apiName", api.Name)
+			failed = true
 			continue // continue with other APIs if this one fails
 		}
 	}
@@ -93,45 +210,47 @@ func (r *SwaggerImportReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// handle each version
 	for _, api := range clusterAPIs.Items {
 		log.Info("Processing matching API", "API Name", api.Name, "Label Matched", appName)
-		version, err := parseVersion(api.Name)
+		version, err := r.apiVersion(&api)
 		if err != nil {
-			log.Error(err, "Failed to parse version from API name", "apiName", api.Name)
+			log.Error(err, "Failed to resolve the version of API", "apiName", api.Name)
 			continue // skip APIs with invalid name format
 		}
-		err = r.fetchAndSaveSwagger(ctx, pod.Namespace, api.Name, "", appName, version)
+		// cluster scoped APIs must allow imports from the namespace of the application
+		permitted, err := r.clusterImportPermitted(ctx, req.Namespace, &api)
+		if err != nil {
+			log.Error(err, "Failed to check the namespaces allowed to import", "apiName", api.Name)
+			failed = true
+			continue
+		}
+		if !permitted {
+			err := clusterNotPermittedError(req.Namespace, api.Name)
+			log.Info("Import into API denied", "apiName", api.Name, "reason", err.Error())
+			r.recordEvent(ctx, pod, api.Name, "", corev1.EventTypeWarning, eventImportDenied, err.Error())
+			continue
+		}
+		err = r.fetchAndSaveSwagger(ctx, pod, target, api.Name, "", appName, version)
+		if isPendingApproval(err) {
+			log.Info("Swagger held for approval", "apiName", api.Name, "reason", err.Error())
+			continue
+		}
+		if isRolloutDivergence(err) {
+			log.Info("Swagger held until the rollout is complete", "apiName", api.Name, "reason", err.Error())
+			continue
+		}
 
 		if err != nil {
 			log.Error(err, "Failed to fetch Swagger JSON", "apiName", api.Name)
+			failed = true
 			continue // continue with other APIs if this one fails
 		}
 	}
 
-	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
-}
-
-// parseVersion extracts the version from an API name in the format "<name>-v<major>.<minor>"
-// Returns the version in the format "v<major>.0" and an error if the format is invalid
-func parseVersion(apiName string) (string, error) {
-	// Check if the API name contains "-v"
-	parts := strings.Split(apiName, "-v")
-	if len(parts) < 2 {
-		return "", fmt.Errorf("API name does not contain '-v': %s", apiName)
+	// back off from applications that keep failing
+	failures := r.trackFailures(failureKindApplication, req.Namespace, appName, failed)
+	if failures > 0 {
+		log.Info("Import failed", "appName", appName, "consecutiveFailures", failures)
 	}
-
-	// Extract the version part after "-v"
-	versionPart := parts[1]
-	if versionPart == "" {
-		return "", fmt.Errorf("version part is empty in API name: %s", apiName)
-	}
-
-	// Split by "." to get the major version
-	versionComponents := strings.Split(versionPart, ".")
-	if len(versionComponents) == 0 || versionComponents[0] == "" {
-		return "", fmt.Errorf("invalid version format in API name: %s", apiName)
-	}
-
-	// Return the version in the format "v<major>.0"
-	return fmt.Sprintf("v%s.0", versionComponents[0]), nil
+	return ctrl.Result{RequeueAfter: r.failureBackoff(1*time.Minute, failures)}, nil
 }
 
 func (r *SwaggerImportReconciler) getPorts(ctx context.Context, namespace, appName string) ([]int32, error) {
@@ -170,109 +289,216 @@ func (r *SwaggerImportReconciler) getPorts(ctx context.Context, namespace, appNa
 	return ports, nil
 }
 
-func (r *SwaggerImportReconciler) needsUpdate(ctx context.Context, apiName, namespaceApi, newSwaggerJSON string) (bool, error) {
-	if namespaceApi == "" {
-		api := &clusterapimanagement.API{}
-		if err := r.Get(ctx, client.ObjectKey{Name: apiName}, api); err != nil {
-			return false, err
-		}
+// fetchAndSaveSwagger imports the swagger document of an application into the API, from the
+// target endpoint when there is one and from the Service otherwise
+func (r *SwaggerImportReconciler) fetchAndSaveSwagger(ctx context.Context, pod *corev1.Pod, target *endpoint, apiName, namespaceApi, appName, version string) error {
+	source, err := r.applicationSource(ctx, pod, target, appName, version)
+	if err != nil {
+		return err
+	}
+
+	return r.importSwagger(ctx, pod, source, apiName, namespaceApi, "")
+}
+
+// applicationSource returns where the swagger document of the given version of an application is served,
+// the target endpoint when there is one and the Service otherwise
+func (r *SwaggerImportReconciler) applicationSource(ctx context.Context, pod *corev1.Pod, target *endpoint, appName, version string) (swaggerSource, error) {
+	source := swaggerSource{
+		namespace: pod.Namespace,
+		service:   appName,
+		version:   version,
+	}
 
-		// match swagger to imports
-		if api.Spec.ForProvider.Import != nil {
-			currentSwaggerJSON := api.Spec.ForProvider.Import.ContentValue
-			return currentSwaggerJSON != nil && *currentSwaggerJSON != newSwaggerJSON, nil
+	if target != nil {
+		// the endpoint serves the target ports of the service
+		source.host = target.address
+		source.ports = target.ports
+	} else {
+		ports, err := r.getPorts(ctx, pod.Namespace, appName)
+		if err != nil {
+			r.Log.Error(err, "Failed to get service ports", "appName", appName)
+			return swaggerSource{}, err
 		}
-		return true, nil
+		source.ports = ports
 	}
+	source.template, source.scheme = r.swaggerLocation(ctx, pod.Namespace, appName, pod)
 
-	api := &namespacedapimanagement.API{}
-	if err := r.Get(ctx, client.ObjectKey{Name: apiName, Namespace: namespaceApi}, api); err != nil {
-		return false, err
+	return source, nil
+}
+
+// importSwagger fetches the swagger document of the source and imports it into the API.
+// An empty contentFormat is detected from the fetched document. Every attempt is recorded as
+// an event on the trigger of the import and on the API.
+func (r *SwaggerImportReconciler) importSwagger(ctx context.Context, trigger client.Object, source swaggerSource, apiName, namespaceApi, contentFormat string) error {
+	swaggerJSON, err := r.fetchDocument(ctx, source)
+	if isRolloutDivergence(err) {
+		r.recordEvent(ctx, trigger, apiName, namespaceApi, corev1.EventTypeNormal, eventRolloutInProgress, err.Error())
+		return err
+	}
+	if err != nil {
+		r.recordFailure(ctx, trigger, apiName, namespaceApi, eventFetchFailed, err)
+		return err
+	}
+	r.recordEvent(ctx, trigger, apiName, namespaceApi, corev1.EventTypeNormal, eventFetched,
+		fmt.Sprintf("Fetched swagger document from service %s", source.service))
+	specSizeBytes.WithLabelValues(apiName, namespaceApi).Set(float64(len(swaggerJSON)))
+
+	if contentFormat == "" {
+		contentFormat, swaggerJSON, err = detectContentFormat(swaggerJSON)
+		if err != nil {
+			err = &validationError{err: err}
+			r.Log.Error(err, "Failed to detect content format", "APIName", apiName)
+			validationFailuresTotal.WithLabelValues(apiName, namespaceApi).Inc()
+			r.recordFailure(ctx, trigger, apiName, namespaceApi, eventValidationFailed, err)
+			return err
+		}
+		r.Log.Info("Detected content format", "APIName", apiName, "ContentFormat", contentFormat)
 	}
 
-	// match swagger to imports
-	if api.Spec.ForProvider.Import != nil {
-		currentSwaggerJSON := api.Spec.ForProvider.Import.ContentValue
-		return currentSwaggerJSON != nil && *currentSwaggerJSON != newSwaggerJSON, nil
+	doc, err := validatedDocument(ctx, contentFormat, swaggerJSON)
+	if err != nil {
+		r.Log.Error(err, "Swagger validation failed; API will not be patched", "APIName", apiName)
+		validationFailuresTotal.WithLabelValues(apiName, namespaceApi).Inc()
+		r.recordFailure(ctx, trigger, apiName, namespaceApi, eventValidationFailed, err)
+		return err
+	}
+	if doc != nil {
+		specOperations.WithLabelValues(apiName, namespaceApi).Set(float64(operationCount(doc)))
 	}
-	return true, nil
-}
 
-func (r *SwaggerImportReconciler) fetchAndSaveSwagger(ctx context.Context, namespace, apiName, namespaceApi, appName, version string) error {
-	ports, err := r.getPorts(ctx, namespace, appName)
+	// Check if update is necessary
+	decision, err := r.apiUpdateDecision(ctx, apiName, namespaceApi, contentFormat, swaggerJSON)
 	if err != nil {
-		r.Log.Error(err, "Failed to get service ports", "appName", appName)
+		r.Log.Error(err, "Error checking if update is needed")
 		return err
 	}
 
+	if decision.Reason == updateReasonPendingApproval {
+		if err := r.recordPendingChange(ctx, apiName, namespaceApi, decision); err != nil {
+			return err
+		}
+		importsTotal.WithLabelValues(apiName, namespaceApi, resultHeld, string(decision.Reason)).Inc()
+		err := &pendingApprovalError{hash: decision.PendingHash, changes: decision.BreakingChanges}
+		r.recordEvent(ctx, trigger, apiName, namespaceApi, corev1.EventTypeWarning, eventPendingApproval, err.Error())
+		return err
+	}
+
+	if decision.Update {
+		for _, change := range decision.BreakingChanges {
+			r.Log.Info("Breaking change detected", "APIName", apiName, "Kind", change.Kind, "Location", change.Location, "Detail", change.Detail)
+		}
+		if err := r.patchAPIResource(ctx, apiName, namespaceApi, swaggerJSON, contentFormat, decision.BreakingChanges); err != nil {
+			r.recordFailure(ctx, trigger, apiName, namespaceApi, eventImportFailed, err)
+			return err
+		}
+		importsTotal.WithLabelValues(apiName, namespaceApi, resultApplied, string(decision.Reason)).Inc()
+		lastSyncTimestamp.WithLabelValues(apiName, namespaceApi).SetToCurrentTime()
+		r.recordEvent(ctx, trigger, apiName, namespaceApi, corev1.EventTypeNormal, eventImported,
+			fmt.Sprintf("Imported %s document into API %s (%s, %d breaking changes)", contentFormat, apiName, decision.Reason, len(decision.BreakingChanges)))
+		return nil
+	}
+
+	r.Log.Info("API is up to date; no update required", "APIName", apiName)
+	if err := r.clearFailure(ctx, apiName, namespaceApi); err != nil {
+		return err
+	}
+	importsTotal.WithLabelValues(apiName, namespaceApi, resultSkipped, string(decision.Reason)).Inc()
+	lastSyncTimestamp.WithLabelValues(apiName, namespaceApi).SetToCurrentTime()
+	r.recordEvent(ctx, trigger, apiName, namespaceApi, corev1.EventTypeNormal, eventUpToDate,
+		fmt.Sprintf("API %s is up to date", apiName))
+	return nil
+}
+
+// fetchFromSource tries each port of the source in order and returns the first swagger document found.
+// Sources without a configured location probe the well-known locations of common frameworks.
+func (r *SwaggerImportReconciler) fetchFromSource(ctx context.Context, source swaggerSource) (string, error) {
+	templates := []string{source.template}
+	if source.template == "" {
+		templates = r.discoveryTemplates(source)
+	}
+
 	var lastError error
-	for _, port := range ports {
-		swaggerURL := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/swagger/%s/swagger.json", appName, namespace, port, version)
+	unreachable := map[int32]bool{}
+	for _, template := range templates {
+		candidate := source
+		candidate.template = template
+
+		for _, port := range source.ports {
+			if unreachable[port] {
+				continue
+			}
+			swaggerURL := candidate.url(port)
 
-		// Use a function to ensure defer runs after each iteration
-		err := func() error {
-			resp, err := r.HTTPGet(swaggerURL)
+			start := time.Now()
+			swaggerJSON, err := r.fetchWithRetry(ctx, swaggerURL)
+			outcome := outcomeSuccess
 			if err != nil {
-				return err
+				outcome = outcomeFailure
+			}
+			fetchDuration.WithLabelValues(source.service, strconv.Itoa(int(port)), outcome).Observe(time.Since(start).Seconds())
+			if err != nil {
+				lastError = err
+				// the fetch was already retried, the other locations on the port are not served either, and
+				// retrying them on an overloaded port would hold the worker for every location
+				unreachable[port] = isUnreachable(err) || isTransient(err)
+				continue
 			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode == http.StatusOK {
-				swaggerJSON, err := io.ReadAll(resp.Body)
-				if err != nil {
-					return err
-				}
-
-				r.Log.Info("Swagger JSON fetched successfully", "URL", swaggerURL)
-				swaggerJSONString := string(swaggerJSON)
 
-				// Check if update is necessary
-				needsUpdate, err := r.needsUpdate(ctx, apiName, namespaceApi, swaggerJSONString)
+			// discovered locations must serve a document, not e.g. the HTML of a catch-all route
+			if source.template == "" {
+				contentFormat, content, err := detectContentFormat(string(swaggerJSON))
 				if err != nil {
-					r.Log.Error(err, "Error checking if update is needed")
-					return err
+					r.Log.V(1).Info("Skipping location without a swagger document", "URL", swaggerURL, "Reason", err.Error())
+					lastError = &validationError{err: fmt.Errorf("%s: %w", swaggerURL, err)}
+					continue
 				}
-
-				if needsUpdate {
-					if err := r.patchAPIResource(ctx, apiName, namespaceApi, swaggerJSONString); err != nil {
-						return err
+				// locations without the version may serve the document of another version
+				if source.version != "" && !versionedTemplate(template) {
+					if err := r.claimLocation(ctx, source, template, contentFormat, content); err != nil {
+						r.Log.V(1).Info("Skipping location serving another version", "URL", swaggerURL, "Reason", err.Error())
+						lastError = &validationError{err: fmt.Errorf("%s: %w", swaggerURL, err)}
+						continue
 					}
-				} else {
-					r.Log.Info("API is up to date; no update required", "APIName", apiName)
 				}
-
-				return nil
 			}
-			return fmt.Errorf("swagger version not found or invalid: %s, HTTP status: %d", version, resp.StatusCode)
-		}()
 
-		if err == nil {
-			return nil
+			r.Log.Info("Swagger JSON fetched successfully", "URL", swaggerURL)
+			if source.template == "" {
+				r.rememberTemplate(source, template)
+			}
+			return string(swaggerJSON), nil
 		}
-		lastError = err
 	}
 
-	return lastError // return error if all fails
-}
+	if lastError == nil {
+		lastError = fmt.Errorf("no ports to fetch swagger from for service: %s", source.service)
+	}
 
-func (r *SwaggerImportReconciler) patchAPIResource(ctx context.Context, apiName string, namespaceApi string, swaggerJSON string) error {
-	contentFormat := "openapi+json"
+	return "", lastError // return error if all fails
+}
 
+// patchAPIResource imports the swagger document into the API with a merge patch that only contains the
+// import fields and annotations managed by the importer, so concurrent changes to other fields are kept
+func (r *SwaggerImportReconciler) patchAPIResource(ctx context.Context, apiName, namespaceApi, swaggerJSON, contentFormat string, breakingChanges []breakingChange) error {
 	if namespaceApi == "" {
 		api := &clusterapimanagement.API{}
 		if err := r.Get(ctx, client.ObjectKey{Name: apiName}, api); err != nil {
 			return err
 		}
+		original := api.DeepCopy()
 
-		// patch swagger into API resource spec.forProvider.import
-		importSpec := clusterapimanagement.ImportParameters{
-			ContentFormat: &contentFormat,
-			ContentValue:  &swaggerJSON,
+		// patch swagger into API resource spec.forProvider.import, keeping fields like the WSDL selector
+		if api.Spec.ForProvider.Import == nil {
+			api.Spec.ForProvider.Import = &clusterapimanagement.ImportParameters{}
 		}
-
-		api.Spec.ForProvider.Import = &importSpec
-
-		if err := r.Update(ctx, api); err != nil {
+		api.Spec.ForProvider.Import.ContentFormat = &contentFormat
+		api.Spec.ForProvider.Import.ContentValue = &swaggerJSON
+		markResynced(api)
+		recordBreakingChanges(api, breakingChanges)
+		clearPendingChange(api)
+		markSynced(api, documentHash(swaggerJSON, r.CompareIgnoreFields))
+
+		if err := r.Patch(ctx, api, client.MergeFrom(original), client.FieldOwner(fieldManager)); err != nil {
 			return err
 		}
 
@@ -284,16 +510,20 @@ func (r *SwaggerImportReconciler) patchAPIResource(ctx context.Context, apiName
 	if err := r.Get(ctx, client.ObjectKey{Name: apiName, Namespace: namespaceApi}, api); err != nil {
 		return err
 	}
+	original := api.DeepCopy()
 
-	// patch swagger into API resource spec.forProvider.import
-	importSpec := namespacedapimanagement.ImportParameters{
-		ContentFormat: &contentFormat,
-		ContentValue:  &swaggerJSON,
+	// patch swagger into API resource spec.forProvider.import, keeping fields like the WSDL selector
+	if api.Spec.ForProvider.Import == nil {
+		api.Spec.ForProvider.Import = &namespacedapimanagement.ImportParameters{}
 	}
-
-	api.Spec.ForProvider.Import = &importSpec
-
-	if err := r.Update(ctx, api); err != nil {
+	api.Spec.ForProvider.Import.ContentFormat = &contentFormat
+	api.Spec.ForProvider.Import.ContentValue = &swaggerJSON
+	markResynced(api)
+	recordBreakingChanges(api, breakingChanges)
+	clearPendingChange(api)
+	markSynced(api, documentHash(swaggerJSON, r.CompareIgnoreFields))
+
+	if err := r.Patch(ctx, api, client.MergeFrom(original), client.FieldOwner(fieldManager)); err != nil {
 		return err
 	}
 
@@ -301,13 +531,141 @@ func (r *SwaggerImportReconciler) patchAPIResource(ctx context.Context, apiName
 	return nil
 }
 
+// applicationPod returns the newest ready pod of an application, the one serving the most recent rollout,
+// or nil when the application has no ready pods
+func (r *SwaggerImportReconciler) applicationPod(ctx context.Context, namespace, appName string) (*corev1.Pod, error) {
+	selector, err := r.applicationPodSelector(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	var newest *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !isPodReady(pod) {
+			continue
+		}
+		if newest == nil || newest.CreationTimestamp.Before(&pod.CreationTimestamp) ||
+			(newest.CreationTimestamp.Equal(&pod.CreationTimestamp) && pod.Name > newest.Name) {
+			newest = pod
+		}
+	}
+	return newest, nil
+}
+
+// applicationRequests maps an imported pod to the request of its application
+func (r *SwaggerImportReconciler) applicationRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	appName, found := obj.GetLabels()[r.appLabel()]
+	if !found {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: appName}}}
+}
+
+// apiRequests maps an API to the requests of the applications in its application label, so created APIs
+// and edits of their import are reconciled without waiting for a pod event
+func (r *SwaggerImportReconciler) apiRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	appName, found := obj.GetLabels()[r.apiLabel()]
+	if !found {
+		return nil
+	}
+	apiSelector, err := r.applicationAPISelector(appName)
+	if err != nil || !apiSelector.Matches(labels.Set(obj.GetLabels())) {
+		return nil
+	}
+	podSelector, err := r.applicationPodSelector(appName)
+	if err != nil {
+		return nil
+	}
+
+	// the pods of the application may run in any namespace
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.MatchingLabelsSelector{Selector: podSelector}); err != nil {
+		r.Log.Error(err, "Failed to list pods of API", "apiName", obj.GetName(), "appName", appName)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	seen := map[string]bool{}
+	for _, pod := range pods.Items {
+		if seen[pod.Namespace] {
+			continue
+		}
+		seen[pod.Namespace] = true
+		var permitted bool
+		var err error
+		if obj.GetNamespace() == "" {
+			permitted, err = r.clusterImportPermitted(ctx, pod.Namespace, obj)
+		} else {
+			permitted, err = r.importPermitted(ctx, pod.Namespace, obj.GetName(), obj.GetNamespace())
+		}
+		if err != nil || !permitted {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: appName}})
+	}
+	return requests
+}
+
+// serviceRequests maps a Service to the request of the application of the same name. Services without
+// selected pods of that application, e.g. cluster DNS or ingress controllers, are not mapped.
+func (r *SwaggerImportReconciler) serviceRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	podSelector, err := r.applicationPodSelector(obj.GetName())
+	if err != nil {
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(obj.GetNamespace()), client.MatchingLabelsSelector{Selector: podSelector}, client.Limit(1)); err != nil {
+		r.Log.Error(err, "Failed to list pods of Service", "service", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+	if len(pods.Items) == 0 {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}}}
+}
+
+// importAnnotationChanged passes updates of APIs that approve a held document or request a resync. Other annotations
+// are written by the importer itself and must not trigger another import.
+var importAnnotationChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		for _, key := range []string{approvedHashAnnotation, resyncAnnotation} {
+			if e.ObjectOld.GetAnnotations()[key] != e.ObjectNew.GetAnnotations()[key] {
+				return true
+			}
+		}
+		return false
+	},
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *SwaggerImportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// status updates of APIs, e.g. by Crossplane, do not need a new import
+	apiChanged := builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}, importAnnotationChanged))
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Pod{}).
-		WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
-			// only applications with label swaggerimporter = true will trigger reconcile
-			return obj.GetLabels()["swaggerimporter"] == "true"
-		})).
+		Named("application").
+		// pods of an application share a request, so the work queue deduplicates their events
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.applicationRequests),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				// only pods selected for import, by default labelled swaggerimporter = true, will trigger reconcile
+				return r.selectsPod(obj.GetLabels())
+			}))).
+		Watches(&namespacedapimanagement.API{}, handler.EnqueueRequestsFromMapFunc(r.apiRequests), apiChanged).
+		Watches(&clusterapimanagement.API{}, handler.EnqueueRequestsFromMapFunc(r.apiRequests), apiChanged).
+		// ports and swagger location annotations of the Service of an application
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.serviceRequests)).
 		Complete(r)
 }
@@ -1,11 +1,9 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
-	"io"
-	"net/http"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -19,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestControllers(t *testing.T) {
@@ -42,7 +41,7 @@ var _ = Describe("SwaggerImportReconciler", func() {
 		_ = namespacedapimanagement.AddToScheme(scheme)
 		_ = clusterapimanagement.AddToScheme(scheme)
 
-		mockSwaggerJSON = `{"swagger": "2.0", "info": {"title": "Mock API", "version": "1.0.0"}}`
+		mockSwaggerJSON = `{"swagger": "2.0", "info": {"title": "Mock API", "version": "1.0.0"}, "paths": {}}`
 	})
 
 	Context("When a Pod with swaggerimporter label exists", func() {
@@ -72,6 +71,7 @@ var _ = Describe("SwaggerImportReconciler", func() {
 						},
 					},
 				},
+				Status: readyPodStatus,
 			}
 
 			api := &namespacedapimanagement.API{
@@ -107,17 +107,14 @@ var _ = Describe("SwaggerImportReconciler", func() {
 				Client: fakeClient,
 				Scheme: scheme,
 				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
-				HTTPGet: func(url string) (*http.Response, error) {
-					return &http.Response{
-						StatusCode: http.StatusOK,
-						Body:       io.NopCloser(bytes.NewBufferString(mockSwaggerJSON)),
-					}, nil
-				},
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					return []byte(mockSwaggerJSON), nil
+				}),
 			}
 
 			req := ctrl.Request{
 				NamespacedName: types.NamespacedName{
-					Name:      podName,
+					Name:      appName,
 					Namespace: namespacePod,
 				},
 			}
@@ -132,6 +129,7 @@ var _ = Describe("SwaggerImportReconciler", func() {
 			Expect(updatedAPI.Spec.ForProvider.Import).NotTo(BeNil())
 			Expect(updatedAPI.Spec.ForProvider.Import.ContentValue).NotTo(BeNil())
 			Expect(*updatedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+			Expect(*updatedAPI.Spec.ForProvider.Import.ContentFormat).To(Equal("swagger-json"))
 		})
 	})
 
@@ -162,6 +160,7 @@ var _ = Describe("SwaggerImportReconciler", func() {
 						},
 					},
 				},
+				Status: readyPodStatus,
 			}
 
 			api := &clusterapimanagement.API{
@@ -196,17 +195,14 @@ var _ = Describe("SwaggerImportReconciler", func() {
 				Client: fakeClient,
 				Scheme: scheme,
 				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
-				HTTPGet: func(url string) (*http.Response, error) {
-					return &http.Response{
-						StatusCode: http.StatusOK,
-						Body:       io.NopCloser(bytes.NewBufferString(mockSwaggerJSON)),
-					}, nil
-				},
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					return []byte(mockSwaggerJSON), nil
+				}),
 			}
 
 			req := ctrl.Request{
 				NamespacedName: types.NamespacedName{
-					Name:      podName,
+					Name:      appName,
 					Namespace: namespacePod,
 				},
 			}
@@ -224,7 +220,351 @@ var _ = Describe("SwaggerImportReconciler", func() {
 		})
 	})
 
-	Context("parseVersion function", func() {
+	Context("When a matching API has import settings", func() {
+		appName := "soap-app"
+		serviceName := "SoapService"
+		endpointName := "SoapEndpoint"
+
+		reconcilePod := func(api client.Object) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "soap-pod",
+					Namespace: "services",
+					Labels: map[string]string{
+						"swaggerimporter": "true",
+						"app":             appName,
+					},
+				},
+				Status: readyPodStatus,
+			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: appName, Namespace: "services"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+			}
+
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod, service, api).Build()
+			reconciler = &SwaggerImportReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					return []byte(mockSwaggerJSON), nil
+				}),
+			}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: appName, Namespace: "services"}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("should keep the settings of a namespaced API", func() {
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      appName + "-v1",
+					Namespace: "services",
+					Labels:    map[string]string{"application": appName},
+				},
+			}
+			api.Spec.ForProvider.Import = &namespacedapimanagement.ImportParameters{
+				WsdlSelector: &namespacedapimanagement.WsdlSelectorParameters{
+					ServiceName:  &serviceName,
+					EndpointName: &endpointName,
+				},
+			}
+
+			reconcilePod(api)
+
+			updatedAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(api), updatedAPI)).To(Succeed())
+			Expect(*updatedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+			Expect(updatedAPI.Spec.ForProvider.Import.WsdlSelector).To(Equal(api.Spec.ForProvider.Import.WsdlSelector))
+		})
+
+		It("should keep the settings of a cluster API", func() {
+			api := &clusterapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   appName + "-v1",
+					Labels: map[string]string{"application": appName},
+				},
+			}
+			api.Spec.ForProvider.Import = &clusterapimanagement.ImportParameters{
+				WsdlSelector: &clusterapimanagement.WsdlSelectorParameters{
+					ServiceName:  &serviceName,
+					EndpointName: &endpointName,
+				},
+			}
+
+			reconcilePod(api)
+
+			updatedAPI := &clusterapimanagement.API{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(api), updatedAPI)).To(Succeed())
+			Expect(*updatedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+			Expect(updatedAPI.Spec.ForProvider.Import.WsdlSelector).To(Equal(api.Spec.ForProvider.Import.WsdlSelector))
+		})
+	})
+
+	Context("When an application has several pods", func() {
+		pod := func(name string, created time.Time, ready bool) *corev1.Pod {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         "services",
+					CreationTimestamp: metav1.NewTime(created),
+					Labels: map[string]string{
+						"swaggerimporter": "true",
+						"app":             "payments",
+					},
+				},
+			}
+			if ready {
+				pod.Status = readyPodStatus
+			}
+			return pod
+		}
+
+		It("should map the pods to a single request of the application", func() {
+			created := time.Now()
+			mapper := &SwaggerImportReconciler{}
+			requests := append(mapper.applicationRequests(ctx, pod("payments-a", created, true)), mapper.applicationRequests(ctx, pod("payments-b", created, false))...)
+			Expect(requests).To(ConsistOf(
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}},
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}},
+			))
+
+			Expect(mapper.applicationRequests(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "payments-c"}})).To(BeEmpty())
+		})
+
+		It("should fetch once per application from the newest ready pod", func() {
+			created := time.Now().Add(-time.Hour)
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments-v1",
+					Namespace: "services",
+					Labels:    map[string]string{"application": "payments"},
+				},
+			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "services"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+			}
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				pod("payments-old", created, true),
+				pod("payments-new", created.Add(time.Minute), true),
+				pod("payments-starting", created.Add(2*time.Minute), false),
+				api, service,
+			).Build()
+
+			fetches := 0
+			reconciler = &SwaggerImportReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					fetches++
+					return []byte(mockSwaggerJSON), nil
+				}),
+			}
+
+			newest, err := reconciler.applicationPod(ctx, "services", "payments")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newest.Name).To(Equal("payments-new"))
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fetches).To(Equal(1))
+		})
+	})
+
+	Context("When an API or Service of an application changes", func() {
+		It("should map the API to the applications of its pods", func() {
+			pod := func(name, namespace string) *corev1.Pod {
+				return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels:    map[string]string{"swaggerimporter": "true", "app": "payments"},
+				}}
+			}
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				pod("payments-a", "services"), pod("payments-b", "services"), pod("payments-c", "staging"),
+			).Build()
+			reconciler = &SwaggerImportReconciler{
+				Client: fakeClient,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			}
+
+			api := &clusterapimanagement.API{ObjectMeta: metav1.ObjectMeta{
+				Name:   "payments-v1",
+				Labels: map[string]string{"application": "payments"},
+			}}
+			Expect(reconciler.apiRequests(ctx, api)).To(ConsistOf(
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}},
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "staging"}},
+			))
+
+			unlabelled := &namespacedapimanagement.API{ObjectMeta: metav1.ObjectMeta{Name: "payments-v1", Namespace: "services"}}
+			Expect(reconciler.apiRequests(ctx, unlabelled)).To(BeEmpty())
+		})
+
+		It("should map the Service to the application of the same name", func() {
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:      "payments-a",
+				Namespace: "services",
+				Labels:    map[string]string{"swaggerimporter": "true", "app": "payments"},
+			}}).Build()
+			reconciler = &SwaggerImportReconciler{
+				Client: fakeClient,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			}
+
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "services"}}
+			Expect(reconciler.serviceRequests(ctx, service)).To(ConsistOf(
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}},
+			))
+
+			unrelated := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"}}
+			Expect(reconciler.serviceRequests(ctx, unrelated)).To(BeEmpty())
+		})
+	})
+
+	Context("When a Pod overrides the swagger path with an annotation", func() {
+		It("should fetch swagger from the annotated path", func() {
+			appName := "spring-app"
+			namespacePod := "services"
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "spring-pod",
+					Namespace: namespacePod,
+					Labels: map[string]string{
+						"swaggerimporter": "true",
+						"app":             appName,
+					},
+					Annotations: map[string]string{
+						"swaggerimporter/path": "/v3/api-docs",
+					},
+				},
+				Status: readyPodStatus,
+			}
+
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "spring-app-v1",
+					Namespace: namespacePod,
+					Labels: map[string]string{
+						"application": appName,
+					},
+				},
+			}
+
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      appName,
+					Namespace: namespacePod,
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{
+						{Port: 8080},
+					},
+				},
+			}
+
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod, api, service).Build()
+
+			var requestedURL string
+			reconciler = &SwaggerImportReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					requestedURL = url
+					return []byte(mockSwaggerJSON), nil
+				}),
+			}
+
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      appName,
+					Namespace: namespacePod,
+				},
+			}
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requestedURL).To(Equal("http://spring-app.services.svc.cluster.local:8080/v3/api-docs"))
+		})
+	})
+
+	Context("When a Pod serves an invalid swagger document", func() {
+		It("should not patch the API resource", func() {
+			appName := "broken-app"
+			namespacePod := "services"
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "broken-pod",
+					Namespace: namespacePod,
+					Labels: map[string]string{
+						"swaggerimporter": "true",
+						"app":             appName,
+					},
+				},
+				Status: readyPodStatus,
+			}
+
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "broken-app-v1",
+					Namespace: namespacePod,
+					Labels: map[string]string{
+						"application": appName,
+					},
+				},
+			}
+
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      appName,
+					Namespace: namespacePod,
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{
+						{Port: 8080},
+					},
+				},
+			}
+
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod, api, service).Build()
+
+			reconciler = &SwaggerImportReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					return []byte("<html><body>502 Bad Gateway</body></html>"), nil
+				}),
+			}
+
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      appName,
+					Namespace: namespacePod,
+				},
+			}
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedAPI := &namespacedapimanagement.API{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: "broken-app-v1", Namespace: namespacePod}, updatedAPI)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedAPI.Spec.ForProvider.Import).To(BeNil())
+		})
+	})
+
+	Context("name version strategy", func() {
+		parseVersion := func(apiName string) (string, error) {
+			return (&SwaggerImportReconciler{}).apiVersion(&namespacedapimanagement.API{ObjectMeta: metav1.ObjectMeta{Name: apiName}})
+		}
+
 		It("should correctly parse valid API name with version", func() {
 			version, err := parseVersion("test-app-v1.2.3")
 			Expect(err).NotTo(HaveOccurred())
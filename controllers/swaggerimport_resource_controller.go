@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	importerv1alpha1 "github.com/fortytwoservices/swagger-importer/api/v1alpha1"
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// defaultImportInterval is the interval between imports when a SwaggerImport does not set one
+const defaultImportInterval = 1 * time.Minute
+
+// SwaggerImportResourceReconciler reconciles SwaggerImport objects using the
+// fetch and patch pipeline of the SwaggerImportReconciler
+type SwaggerImportResourceReconciler struct {
+	*SwaggerImportReconciler
+}
+
+//+kubebuilder:rbac:groups=importer.swagger-importer.com,resources=swaggerimports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=importer.swagger-importer.com,resources=swaggerimports/status,verbs=get;update;patch
+
+// Reconcile function to reconcile SwaggerImport
+func (r *SwaggerImportResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("swaggerimport", req.NamespacedName)
+
+	var swaggerImport importerv1alpha1.SwaggerImport
+	if err := r.Get(ctx, req.NamespacedName, &swaggerImport); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("SwaggerImport not found, will not requeue")
+			r.forgetFailures(failureKindSwaggerImport, req.Namespace, req.Name)
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get SwaggerImport, requeuing")
+		return ctrl.Result{}, err
+	}
+
+	interval := defaultImportInterval
+	if swaggerImport.Spec.Interval != nil && swaggerImport.Spec.Interval.Duration > 0 {
+		interval = swaggerImport.Spec.Interval.Duration
+	}
+
+	apiName, namespaceApi := apiTarget(&swaggerImport)
+
+	// namespaced APIs of other namespaces must grant imports from the namespace of the SwaggerImport
+	if namespaceApi != "" {
+		permitted, err := r.importPermitted(ctx, swaggerImport.Namespace, apiName, namespaceApi)
+		if err != nil {
+			log.Error(err, "Failed to list SwaggerImportGrants", "apiNamespace", namespaceApi)
+			return ctrl.Result{}, err
+		}
+		if !permitted {
+			err := notPermittedError(swaggerImport.Namespace, apiName, namespaceApi)
+			log.Info("Import into API not permitted", "apiName", apiName, "reason", err.Error())
+			return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, &swaggerImport, importerv1alpha1.ReasonReferenceNotPermitted, err)
+		}
+	}
+
+	// cluster scoped APIs must allow imports from the namespace of the SwaggerImport
+	if namespaceApi == "" {
+		api := &clusterapimanagement.API{}
+		if err := r.Get(ctx, client.ObjectKey{Name: apiName}, api); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get API", "apiName", apiName)
+			return ctrl.Result{}, err
+		}
+		permitted, err := r.clusterImportPermitted(ctx, swaggerImport.Namespace, api)
+		if err != nil {
+			log.Error(err, "Failed to check the namespaces allowed to import", "apiName", apiName)
+			return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, &swaggerImport, importerv1alpha1.ReasonReferenceNotPermitted, err)
+		}
+		if !permitted {
+			err := clusterNotPermittedError(swaggerImport.Namespace, apiName)
+			log.Info("Import into API denied", "apiName", apiName, "reason", err.Error())
+			r.recordEvent(ctx, &swaggerImport, apiName, "", corev1.EventTypeWarning, eventImportDenied, err.Error())
+			return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, &swaggerImport, importerv1alpha1.ReasonReferenceNotPermitted, err)
+		}
+	}
+
+	version := swaggerImport.Spec.Version
+	if version == "" {
+		// the version is preferably read from the spec of the API, otherwise resolved by the version strategy
+		api, key := apiObject(apiName, namespaceApi)
+		if err := r.Get(ctx, key, api); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get API", "apiName", apiName)
+			return ctrl.Result{}, err
+		}
+		api.SetName(apiName)
+		resolved, err := r.apiVersion(api)
+		if err != nil {
+			log.Error(err, "Failed to resolve the version of API", "apiName", apiName)
+			// the API may not exist yet, SwaggerImports are only watched for changes of their spec
+			return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, &swaggerImport, importerv1alpha1.ReasonInvalidTarget, err)
+		}
+		version = resolved
+	}
+
+	source := swaggerSource{
+		namespace: swaggerImport.Namespace,
+		service:   swaggerImport.Spec.Source.Service,
+		version:   version,
+	}
+	source.template, source.scheme = r.swaggerLocation(ctx, source.namespace, source.service, nil)
+	if swaggerImport.Spec.Path != "" {
+		source.template = swaggerImport.Spec.Path
+	}
+	if swaggerImport.Spec.Source.Port != 0 {
+		source.ports = []int32{swaggerImport.Spec.Source.Port}
+	} else {
+		ports, err := r.getPorts(ctx, source.namespace, source.service)
+		if err != nil {
+			log.Error(err, "Failed to get service ports", "service", source.service)
+			return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, &swaggerImport, importerv1alpha1.ReasonImportFailed, err)
+		}
+		source.ports = ports
+	}
+
+	log.Info("Processing SwaggerImport", "API Name", apiName, "Service", source.service)
+	reason := importerv1alpha1.ReasonImported
+	importErr := r.importSwagger(ctx, &swaggerImport, source, apiName, namespaceApi, swaggerImport.Spec.Format)
+	if importErr != nil {
+		log.Error(importErr, "Failed to import Swagger JSON", "apiName", apiName)
+		reason = importerv1alpha1.ReasonImportFailed
+		if isValidationError(importErr) {
+			reason = importerv1alpha1.ReasonValidationFailed
+		}
+		if isPendingApproval(importErr) {
+			reason = importerv1alpha1.ReasonPendingApproval
+		}
+		if isRolloutDivergence(importErr) {
+			reason = importerv1alpha1.ReasonRolloutInProgress
+		}
+	}
+
+	failed := importErr != nil && !isPendingApproval(importErr) && !isRolloutDivergence(importErr)
+	failures := r.trackFailures(failureKindSwaggerImport, swaggerImport.Namespace, swaggerImport.Name, failed)
+	swaggerImport.Status.ConsecutiveFailures = int32(failures)
+	if err := r.updateStatus(ctx, &swaggerImport, reason, importErr); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.failureBackoff(interval, failures)}, nil
+}
+
+// apiTarget returns the API name and namespace referenced by a SwaggerImport.
+// The namespace is empty for cluster scoped APIs.
+func apiTarget(swaggerImport *importerv1alpha1.SwaggerImport) (string, string) {
+	ref := swaggerImport.Spec.APIRef
+	if ref.Scope == importerv1alpha1.APIScopeCluster {
+		return ref.Name, ""
+	}
+	if ref.Namespace == "" {
+		return ref.Name, swaggerImport.Namespace
+	}
+	return ref.Name, ref.Namespace
+}
+
+// updateStatus records the outcome of an import attempt on the SwaggerImport.
+// A nil importErr marks the import as successful.
+func (r *SwaggerImportResourceReconciler) updateStatus(ctx context.Context, swaggerImport *importerv1alpha1.SwaggerImport, reason string, importErr error) error {
+	condition := metav1.Condition{
+		Type:               importerv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            fmt.Sprintf("Swagger imported into API %s", swaggerImport.Spec.APIRef.Name),
+		ObservedGeneration: swaggerImport.Generation,
+	}
+
+	if importErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = importErr.Error()
+	} else {
+		now := metav1.Now()
+		swaggerImport.Status.LastSyncTime = &now
+	}
+
+	swaggerImport.Status.ObservedGeneration = swaggerImport.Generation
+	meta.SetStatusCondition(&swaggerImport.Status.Conditions, condition)
+
+	return r.Status().Update(ctx, swaggerImport)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SwaggerImportResourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// status updates do not change the generation and must not trigger a new import
+		For(&importerv1alpha1.SwaggerImport{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
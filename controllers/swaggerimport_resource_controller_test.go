@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	importerv1alpha1 "github.com/fortytwoservices/swagger-importer/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("SwaggerImportResourceReconciler", func() {
+	var (
+		reconciler      *SwaggerImportResourceReconciler
+		fakeClient      client.Client
+		scheme          *runtime.Scheme
+		ctx             context.Context
+		mockSwaggerJSON string
+		requestedURLs   []string
+		interceptors    interceptor.Funcs
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		_ = corev1.AddToScheme(scheme)
+		_ = namespacedapimanagement.AddToScheme(scheme)
+		_ = importerv1alpha1.AddToScheme(scheme)
+
+		mockSwaggerJSON = `{"openapi": "3.0.1", "info": {"title": "Mock API", "version": "1.0.0"}, "paths": {}}`
+		requestedURLs = nil
+		interceptors = interceptor.Funcs{}
+	})
+
+	newReconciler := func(objects ...client.Object) {
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(&importerv1alpha1.SwaggerImport{}).
+			WithInterceptorFuncs(interceptors).
+			Build()
+
+		reconciler = &SwaggerImportResourceReconciler{
+			SwaggerImportReconciler: &SwaggerImportReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+				Fetcher: FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+					requestedURLs = append(requestedURLs, url)
+					return []byte(mockSwaggerJSON), nil
+				}),
+			},
+		}
+	}
+
+	Context("When a SwaggerImport references a namespaced API", func() {
+		It("should import the swagger from the declared path and report Ready", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments",
+					Namespace: "services",
+				},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source: importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef: importerv1alpha1.APIReference{Name: "payments-v2"},
+					Path:   "/openapi.json",
+					Format: "openapi+json",
+				},
+			}
+
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments-v2",
+					Namespace: "services",
+				},
+			}
+
+			newReconciler(swaggerImport, api)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			Expect(requestedURLs).To(ConsistOf("http://payments.services.svc.cluster.local:8080/openapi.json"))
+
+			updatedAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v2", Namespace: "services"}, updatedAPI)).To(Succeed())
+			Expect(updatedAPI.Spec.ForProvider.Import).NotTo(BeNil())
+			Expect(*updatedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+
+			updatedImport := &importerv1alpha1.SwaggerImport{}
+			Expect(fakeClient.Get(ctx, req.NamespacedName, updatedImport)).To(Succeed())
+			Expect(updatedImport.Status.LastSyncTime).NotTo(BeNil())
+			Expect(meta.IsStatusConditionTrue(updatedImport.Status.Conditions, importerv1alpha1.ConditionReady)).To(BeTrue())
+		})
+
+		It("should record breaking changes on the API", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments",
+					Namespace: "services",
+				},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source: importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef: importerv1alpha1.APIReference{Name: "payments-v2"},
+					Path:   "/openapi.json",
+					Format: "openapi+json",
+				},
+			}
+
+			contentFormat := "openapi+json"
+			previousJSON := `{"openapi": "3.0.1", "info": {"title": "Mock API", "version": "1.0.0"},
+  "paths": {"/refunds": {"get": {"responses": {"200": {"description": "OK"}}}}}}`
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments-v2",
+					Namespace: "services",
+				},
+			}
+			api.Spec.ForProvider.Import = &namespacedapimanagement.ImportParameters{
+				ContentFormat: &contentFormat,
+				ContentValue:  &previousJSON,
+			}
+
+			newReconciler(swaggerImport, api)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v2", Namespace: "services"}, updatedAPI)).To(Succeed())
+			Expect(*updatedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+			Expect(updatedAPI.GetAnnotations()).To(HaveKeyWithValue(breakingChangesAnnotation, `[{"kind":"RemovedPath","location":"/refunds"}]`))
+		})
+
+		It("should keep concurrent changes to the API", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments",
+					Namespace: "services",
+				},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source: importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef: importerv1alpha1.APIReference{Name: "payments-v2"},
+					Path:   "/openapi.json",
+					Format: "openapi+json",
+				},
+			}
+
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments-v2",
+					Namespace: "services",
+				},
+			}
+
+			// another controller changes the API after every read of the importer
+			displayName := "Payments"
+			interceptors.Get = func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				if _, ok := obj.(*namespacedapimanagement.API); !ok {
+					return nil
+				}
+
+				concurrent := &namespacedapimanagement.API{}
+				if err := c.Get(ctx, key, concurrent); err != nil {
+					return err
+				}
+				displayName += "!"
+				concurrent.Spec.ForProvider.DisplayName = &displayName
+				return c.Update(ctx, concurrent)
+			}
+
+			newReconciler(swaggerImport, api)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedImport := &importerv1alpha1.SwaggerImport{}
+			Expect(fakeClient.Get(ctx, req.NamespacedName, updatedImport)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(updatedImport.Status.Conditions, importerv1alpha1.ConditionReady)).To(BeTrue())
+
+			updatedAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v2", Namespace: "services"}, updatedAPI)).To(Succeed())
+			Expect(*updatedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+			Expect(updatedAPI.Spec.ForProvider.DisplayName).NotTo(BeNil())
+		})
+
+		It("should hold breaking changes until they are approved", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments",
+					Namespace: "services",
+				},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source: importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef: importerv1alpha1.APIReference{Name: "payments-v2"},
+					Path:   "/openapi.json",
+					Format: "openapi+json",
+				},
+			}
+
+			contentFormat := "openapi+json"
+			previousJSON := `{"openapi": "3.0.1", "info": {"title": "Mock API", "version": "1.0.0"},
+  "paths": {"/refunds": {"get": {"responses": {"200": {"description": "OK"}}}}}}`
+			api := &namespacedapimanagement.API{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments-v2",
+					Namespace: "services",
+				},
+			}
+			api.Spec.ForProvider.Import = &namespacedapimanagement.ImportParameters{
+				ContentFormat: &contentFormat,
+				ContentValue:  &previousJSON,
+			}
+
+			newReconciler(swaggerImport, api)
+			reconciler.HoldBreakingChanges = true
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			heldAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v2", Namespace: "services"}, heldAPI)).To(Succeed())
+			Expect(*heldAPI.Spec.ForProvider.Import.ContentValue).To(Equal(previousJSON))
+			pendingHash := heldAPI.GetAnnotations()[pendingHashAnnotation]
+			Expect(pendingHash).To(Equal(documentHash(mockSwaggerJSON, nil)))
+			Expect(heldAPI.GetAnnotations()).To(HaveKey(breakingChangesAnnotation))
+
+			updatedImport := &importerv1alpha1.SwaggerImport{}
+			Expect(fakeClient.Get(ctx, req.NamespacedName, updatedImport)).To(Succeed())
+			condition := meta.FindStatusCondition(updatedImport.Status.Conditions, importerv1alpha1.ConditionReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(importerv1alpha1.ReasonPendingApproval))
+
+			heldAPI.Annotations[approvedHashAnnotation] = pendingHash
+			Expect(fakeClient.Update(ctx, heldAPI)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			approvedAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-v2", Namespace: "services"}, approvedAPI)).To(Succeed())
+			Expect(*approvedAPI.Spec.ForProvider.Import.ContentValue).To(Equal(mockSwaggerJSON))
+			Expect(approvedAPI.GetAnnotations()).NotTo(HaveKey(pendingHashAnnotation))
+		})
+
+		It("should hold breaking changes of APIs with a declared version", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "services"},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source: importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef: importerv1alpha1.APIReference{Name: "payments-8fk2x"},
+					Path:   "/openapi.json",
+					Format: "openapi+json",
+				},
+			}
+
+			contentFormat := "openapi+json"
+			previousJSON := `{"openapi": "3.0.1", "info": {"title": "Mock API", "version": "1.0.0"},
+  "paths": {"/refunds": {"get": {"responses": {"200": {"description": "OK"}}}}}}`
+			version := "v1"
+			api := &namespacedapimanagement.API{ObjectMeta: metav1.ObjectMeta{Name: "payments-8fk2x", Namespace: "services"}}
+			api.Spec.ForProvider.Version = &version
+			api.Spec.ForProvider.Import = &namespacedapimanagement.ImportParameters{
+				ContentFormat: &contentFormat,
+				ContentValue:  &previousJSON,
+			}
+
+			newReconciler(swaggerImport, api)
+			reconciler.HoldBreakingChanges = true
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			heldAPI := &namespacedapimanagement.API{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "payments-8fk2x", Namespace: "services"}, heldAPI)).To(Succeed())
+			Expect(*heldAPI.Spec.ForProvider.Import.ContentValue).To(Equal(previousJSON))
+			Expect(heldAPI.GetAnnotations()).To(HaveKey(pendingHashAnnotation))
+		})
+
+		It("should report the failure when the swagger cannot be fetched", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments",
+					Namespace: "services",
+				},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source:   importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef:   importerv1alpha1.APIReference{Name: "payments-v1"},
+					Interval: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			}
+
+			newReconciler(swaggerImport)
+			reconciler.Fetcher = FetcherFunc(func(ctx context.Context, url string) ([]byte, error) {
+				requestedURLs = append(requestedURLs, url)
+				return nil, &statusError{url: url, statusCode: http.StatusNotFound}
+			})
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+			Expect(requestedURLs).To(HaveLen(len(wellKnownPathTemplates)))
+			Expect(requestedURLs[0]).To(Equal("http://payments.services.svc.cluster.local:8080/swagger/v1.0/swagger.json"))
+
+			updatedImport := &importerv1alpha1.SwaggerImport{}
+			Expect(fakeClient.Get(ctx, req.NamespacedName, updatedImport)).To(Succeed())
+			condition := meta.FindStatusCondition(updatedImport.Status.Conditions, importerv1alpha1.ConditionReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(importerv1alpha1.ReasonImportFailed))
+			Expect(updatedImport.Status.ConsecutiveFailures).To(Equal(int32(1)))
+		})
+
+		It("should forget the failures of deleted SwaggerImports", func() {
+			newReconciler()
+			Expect(reconciler.trackFailures(failureKindSwaggerImport, "services", "payments", true)).To(Equal(1))
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, found := reconciler.failures.Load(failureKey(failureKindSwaggerImport, "services", "payments"))
+			Expect(found).To(BeFalse())
+			Expect(consecutiveFailures.DeleteLabelValues(failureKindSwaggerImport, "payments", "services")).To(BeFalse())
+		})
+
+		It("should retry SwaggerImports applied before their API", func() {
+			swaggerImport := &importerv1alpha1.SwaggerImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "payments",
+					Namespace: "services",
+				},
+				Spec: importerv1alpha1.SwaggerImportSpec{
+					Source:   importerv1alpha1.SourceReference{Service: "payments", Port: 8080},
+					APIRef:   importerv1alpha1.APIReference{Name: "payments"},
+					Interval: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			}
+
+			newReconciler(swaggerImport)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "services"}}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+			Expect(requestedURLs).To(BeEmpty())
+
+			updatedImport := &importerv1alpha1.SwaggerImport{}
+			Expect(fakeClient.Get(ctx, req.NamespacedName, updatedImport)).To(Succeed())
+			condition := meta.FindStatusCondition(updatedImport.Status.Conditions, importerv1alpha1.ConditionReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(importerv1alpha1.ReasonInvalidTarget))
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// validationError is returned when a fetched document is not a valid Swagger 2.0 or OpenAPI 3.x document
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string {
+	return fmt.Sprintf("invalid swagger document: %v", e.err)
+}
+
+func (e *validationError) Unwrap() error {
+	return e.err
+}
+
+// isValidationError reports whether err was caused by an invalid swagger document
+func isValidationError(err error) bool {
+	var validationErr *validationError
+	return errors.As(err, &validationErr)
+}
+
+// parseDocument parses a Swagger 2.0 or OpenAPI 3.x document and resolves its references.
+// Swagger 2.0 documents are converted to OpenAPI 3.
+func parseDocument(ctx context.Context, contentFormat, content string) (*openapi3.T, error) {
+	switch contentFormat {
+	case formatSwaggerJSON:
+		var doc2 openapi2.T
+		if err := json.Unmarshal([]byte(content), &doc2); err != nil {
+			return nil, err
+		}
+		doc, err := openapi2conv.ToV3(&doc2)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Paths == nil && doc2.Paths != nil {
+			// the conversion drops an empty paths object, which is valid in Swagger 2.0
+			doc.Paths = openapi3.NewPaths()
+		}
+		return doc, nil
+
+	case formatOpenAPIJSON, formatOpenAPIYAML:
+		loader := openapi3.NewLoader()
+		loader.Context = ctx
+		return loader.LoadFromData([]byte(content))
+	}
+
+	return nil, fmt.Errorf("content format %s is not a Swagger or OpenAPI document", contentFormat)
+}
+
+// validatedDocument checks that a document is a structurally valid Swagger 2.0 or OpenAPI 3.x document
+// with resolvable references and returns it parsed. Documents of other content formats are not validated
+// and their parsed document is nil.
+func validatedDocument(ctx context.Context, contentFormat, content string) (*openapi3.T, error) {
+	switch contentFormat {
+	case formatSwaggerJSON, formatOpenAPIJSON, formatOpenAPIYAML:
+	default:
+		return nil, nil
+	}
+
+	doc, err := parseDocument(ctx, contentFormat, content)
+	if err != nil {
+		return nil, &validationError{err: err}
+	}
+
+	// examples, schema defaults and patterns, which are compiled with RE2 instead of ECMAScript, are accepted by
+	// API Management and not validated
+	if err := doc.Validate(ctx, openapi3.DisableExamplesValidation(), openapi3.DisableSchemaDefaultsValidation(),
+		openapi3.DisableSchemaPatternValidation()); err != nil {
+		return nil, &validationError{err: err}
+	}
+
+	return doc, nil
+}
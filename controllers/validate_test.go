@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("validatedDocument", func() {
+	ctx := context.Background()
+
+	It("should accept a valid Swagger 2.0 document", func() {
+		document := `{
+			"swagger": "2.0",
+			"info": {"title": "Payments", "version": "1.0"},
+			"paths": {"/payments": {"get": {"responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/Payment"}}}}}},
+			"definitions": {"Payment": {"type": "object"}}
+		}`
+		_, err := validatedDocument(ctx, formatSwaggerJSON, document)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should accept a valid OpenAPI 3 YAML document", func() {
+		document := "openapi: 3.0.3\ninfo:\n  title: Payments\n  version: '1.0'\npaths: {}\n"
+		_, err := validatedDocument(ctx, formatOpenAPIYAML, document)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should accept patterns and examples that API Management accepts", func() {
+		document := `{
+			"openapi": "3.0.1",
+			"info": {"title": "Payments", "version": "1.0"},
+			"paths": {},
+			"components": {"schemas": {"Password": {"type": "string", "pattern": "^(?=.*[0-9])(?=.*[a-z]).{8,}$", "example": 42}}}
+		}`
+		_, err := validatedDocument(ctx, formatOpenAPIJSON, document)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject unresolvable references", func() {
+		document := `{
+			"openapi": "3.0.3",
+			"info": {"title": "Payments", "version": "1.0"},
+			"paths": {"/payments": {"get": {"responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}
+		}`
+		_, err := validatedDocument(ctx, formatOpenAPIJSON, document)
+		Expect(err).To(HaveOccurred())
+		Expect(isValidationError(err)).To(BeTrue())
+	})
+
+	It("should reject structurally invalid documents", func() {
+		_, err := validatedDocument(ctx, formatOpenAPIJSON, `{"openapi": "3.0.3", "paths": {}}`)
+		Expect(err).To(HaveOccurred())
+		Expect(isValidationError(err)).To(BeTrue())
+	})
+
+	It("should not validate WSDL documents", func() {
+		_, err := validatedDocument(ctx, formatWSDL, "<definitions/>")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
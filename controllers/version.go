@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Strategies resolving the version of the swagger document of an API
+const (
+	// VersionStrategyName takes the version after the last "-v" of the API name, e.g. payments-v2
+	VersionStrategyName = "name"
+
+	// VersionStrategyRegex takes the named capture group "version" of a pattern matched against the API name
+	VersionStrategyRegex = "regex"
+
+	// VersionStrategyAnnotation takes the version from an annotation or label of the API
+	VersionStrategyAnnotation = "annotation"
+
+	// VersionStrategySemver takes the semantic version after the last "-v" of the API name, e.g. payments-v2.1.3
+	VersionStrategySemver = "semver"
+)
+
+// semverPattern matches semantic versions with optional minor and patch versions and pre-release
+var semverPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,2}(-[0-9A-Za-z.-]+)?$`)
+
+// numericVersion matches versions made up of numbers only, e.g. v2 or 2.1
+var numericVersion = regexp.MustCompile(`^v?\d+(\.\d+)*$`)
+
+// majorPattern matches versions starting with a numeric major version, e.g. 2 or 2.1
+var majorPattern = regexp.MustCompile(`^\d+(\.|$)`)
+
+// Sources of the version of an API, logged when it is resolved
+const (
+	versionSourceSpec       = "spec.forProvider.version"
+	versionSourceName       = "name"
+	versionSourceAnnotation = "annotation"
+	versionSourceLabel      = "label"
+)
+
+// VersionResolver resolves the version of the swagger document imported into an API, which is rendered into
+// the {version} placeholder of the fetch path, and the source it was resolved from
+type VersionResolver interface {
+	Resolve(api client.Object) (version, source string, err error)
+}
+
+// strategyResolver prefers the version declared in the spec of an API and falls back to its strategy
+type strategyResolver struct {
+	extract  func(api client.Object) (version, source string, err error)
+	template string
+
+	// explicit is set when the template is configured instead of the default template of the strategy
+	explicit bool
+}
+
+// Resolve renders the version of the API with the template of the strategy. Declared versions that are not
+// numeric, e.g. dates, are used as is unless the template is configured explicitly.
+func (s *strategyResolver) Resolve(api client.Object) (string, string, error) {
+	if version := declaredVersion(api); version != "" {
+		if !s.explicit && !numericVersion.MatchString(version) {
+			return version, versionSourceSpec, nil
+		}
+		return renderVersion(s.template, version), versionSourceSpec, nil
+	}
+
+	version, source, err := s.extract(api)
+	if err != nil {
+		return "", "", err
+	}
+	return renderVersion(s.template, version), source, nil
+}
+
+// defaultVersions resolves versions with the name strategy
+var defaultVersions, _ = NewVersionResolver(VersionStrategyName, "", "", "")
+
+// NewVersionResolver returns the resolver of a version strategy. The version declared in spec.forProvider.version
+// of an API is preferred over the strategy. The pattern is used by the regex strategy and the key by the annotation
+// strategy. The template renders the resolved version with the {version}, {major}, {minor} and {patch} placeholders,
+// defaulting to v{major}.0 for the name strategy, v{major}.{minor}.{patch} for the semver strategy and {version}
+// otherwise.
+func NewVersionResolver(strategy, pattern, key, template string) (VersionResolver, error) {
+	var extract func(api client.Object) (string, string, error)
+	defaultTemplate := "{version}"
+
+	switch strategy {
+	case VersionStrategyName, "":
+		extract = func(api client.Object) (string, string, error) {
+			version, err := majorVersion(api.GetName())
+			return version, versionSourceName, err
+		}
+		defaultTemplate = "v{major}.0"
+	case VersionStrategyRegex:
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version pattern: %w", err)
+		}
+		group := expression.SubexpIndex("version")
+		if group < 0 {
+			return nil, fmt.Errorf("version pattern %s has no capture group named version", pattern)
+		}
+		extract = func(api client.Object) (string, string, error) {
+			match := expression.FindStringSubmatch(api.GetName())
+			if match == nil || match[group] == "" {
+				return "", "", fmt.Errorf("API name %s does not match the version pattern %s", api.GetName(), pattern)
+			}
+			return match[group], versionSourceName, nil
+		}
+	case VersionStrategyAnnotation:
+		if key == "" {
+			return nil, fmt.Errorf("the annotation version strategy needs an annotation or label key")
+		}
+		extract = func(api client.Object) (string, string, error) {
+			if version := api.GetAnnotations()[key]; version != "" {
+				return version, versionSourceAnnotation, nil
+			}
+			if version := api.GetLabels()[key]; version != "" {
+				return version, versionSourceLabel, nil
+			}
+			return "", "", fmt.Errorf("API %s has no %s annotation or label", api.GetName(), key)
+		}
+	case VersionStrategySemver:
+		extract = func(api client.Object) (string, string, error) {
+			version, err := nameVersion(api.GetName())
+			if err != nil {
+				return "", "", err
+			}
+			if !semverPattern.MatchString(version) {
+				return "", "", fmt.Errorf("version %s of API name %s is not a semantic version", version, api.GetName())
+			}
+			return version, versionSourceName, nil
+		}
+		defaultTemplate = "v{major}.{minor}.{patch}"
+	default:
+		return nil, fmt.Errorf("unknown version strategy %s", strategy)
+	}
+
+	explicit := template != ""
+	if !explicit {
+		template = defaultTemplate
+	}
+	return &strategyResolver{extract: extract, template: template, explicit: explicit}, nil
+}
+
+// declaredVersion returns the version declared in spec.forProvider.version of an API, empty when it has none
+func declaredVersion(api client.Object) string {
+	var version *string
+	switch api := api.(type) {
+	case *namespacedapimanagement.API:
+		version = api.Spec.ForProvider.Version
+	case *clusterapimanagement.API:
+		version = api.Spec.ForProvider.Version
+	}
+	if version == nil {
+		return ""
+	}
+	return *version
+}
+
+// renderVersion expands the {version}, {major}, {minor} and {patch} placeholders of a template. Missing minor
+// and patch versions are rendered as 0.
+func renderVersion(template, version string) string {
+	core, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V"), "-")
+	components := append(strings.SplitN(core, ".", 3), "0", "0")
+
+	return strings.NewReplacer(
+		"{version}", version,
+		"{major}", components[0],
+		"{minor}", components[1],
+		"{patch}", components[2],
+	).Replace(template)
+}
+
+// nameVersion returns the version after the last "-v" of an API name in the format "<name>-v<version>"
+func nameVersion(apiName string) (string, error) {
+	index := strings.LastIndex(apiName, "-v")
+	if index < 0 {
+		return "", fmt.Errorf("API name does not contain '-v': %s", apiName)
+	}
+
+	versionPart := apiName[index+len("-v"):]
+	if versionPart == "" {
+		return "", fmt.Errorf("version part is empty in API name: %s", apiName)
+	}
+	return versionPart, nil
+}
+
+// majorVersion returns the version after the last "-v" of an API name if it starts with a numeric major version
+func majorVersion(apiName string) (string, error) {
+	versionPart, err := nameVersion(apiName)
+	if err != nil {
+		return "", err
+	}
+
+	if !majorPattern.MatchString(versionPart) {
+		return "", fmt.Errorf("invalid version format in API name: %s", apiName)
+	}
+	return versionPart, nil
+}
+
+// versionMajor returns the major version of a resolved version made up of numbers only, e.g. 2 for v2.1.
+// Other versions, e.g. dates like 2024-01-01 or pre-releases, have no major version.
+func versionMajor(version string) (int, error) {
+	if !numericVersion.MatchString(version) {
+		return 0, fmt.Errorf("version %s is not numeric", version)
+	}
+	return strconv.Atoi(strings.Split(strings.TrimPrefix(version, "v"), ".")[0])
+}
+
+// apiVersion resolves the version of the swagger document of an API with the resolver of the reconciler,
+// the name strategy when it has none, and logs the source it was resolved from
+func (r *SwaggerImportReconciler) apiVersion(api client.Object) (string, error) {
+	resolver := r.Versions
+	if resolver == nil {
+		resolver = defaultVersions
+	}
+
+	version, source, err := resolver.Resolve(api)
+	if err != nil {
+		return "", err
+	}
+	r.Log.Info("Resolved version of API", "APIName", api.GetName(), "Version", version, "Source", source)
+	return version, nil
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clusterapimanagement "github.com/upbound/provider-azure/v2/apis/cluster/apimanagement/v1beta2"
+	namespacedapimanagement "github.com/upbound/provider-azure/v2/apis/namespaced/apimanagement/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// withSpecVersion declares the version in the spec of a namespaced API
+func withSpecVersion(api *namespacedapimanagement.API, version string) *namespacedapimanagement.API {
+	api.Spec.ForProvider.Version = ptr.To(version)
+	return api
+}
+
+var _ = Describe("Version strategies", func() {
+	api := func(name string, annotations, labels map[string]string) *namespacedapimanagement.API {
+		return &namespacedapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations, Labels: labels},
+		}
+	}
+
+	DescribeTable("should resolve the version of an API",
+		func(strategy, pattern, key, template string, api *namespacedapimanagement.API, expected string) {
+			resolver, err := NewVersionResolver(strategy, pattern, key, template)
+			Expect(err).NotTo(HaveOccurred())
+
+			version, _, err := resolver.Resolve(api)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(expected))
+		},
+		Entry("name", VersionStrategyName, "", "", "", api("payments-v2.1", nil, nil), "v2.0"),
+		Entry("name with -v in the name", VersionStrategyName, "", "", "", api("my-vault-api-v2", nil, nil), "v2.0"),
+		Entry("name with template", VersionStrategyName, "", "", "{major}", api("payments-v2.1", nil, nil), "2"),
+		Entry("regex", VersionStrategyRegex, `^payments-(?P<version>\d+)-api$`, "", "", api("payments-3-api", nil, nil), "3"),
+		Entry("regex with template", VersionStrategyRegex, `^payments-(?P<version>\d+)-api$`, "", "v{major}", api("payments-3-api", nil, nil), "v3"),
+		Entry("annotation", VersionStrategyAnnotation, "", "swaggerimporter/version", "",
+			api("payments", map[string]string{"swaggerimporter/version": "2024-01"}, nil), "2024-01"),
+		Entry("label", VersionStrategyAnnotation, "", "swaggerimporter/version", "",
+			api("payments", nil, map[string]string{"swaggerimporter/version": "v4"}), "v4"),
+		Entry("semver", VersionStrategySemver, "", "", "", api("payments-v1.4.2", nil, nil), "v1.4.2"),
+		Entry("semver without patch", VersionStrategySemver, "", "", "", api("payments-v1.4", nil, nil), "v1.4.0"),
+		Entry("semver with template", VersionStrategySemver, "", "", "v{major}.{minor}", api("payments-v1.4.2", nil, nil), "v1.4"),
+	)
+
+	DescribeTable("should prefer the version declared in the spec of the API",
+		func(strategy, template string, api client.Object, expected, source string) {
+			resolver, err := NewVersionResolver(strategy, "", "swaggerimporter/version", template)
+			Expect(err).NotTo(HaveOccurred())
+
+			version, resolvedSource, err := resolver.Resolve(api)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(expected))
+			Expect(resolvedSource).To(Equal(source))
+		},
+		Entry("namespaced API", VersionStrategyName, "", withSpecVersion(api("payments-v1", nil, nil), "v3"), "v3.0", versionSourceSpec),
+		Entry("baseline URL of a versioned name", VersionStrategyName, "", withSpecVersion(api("payments-v1", nil, nil), "v1"), "v1.0", versionSourceSpec),
+		Entry("generated name", VersionStrategyName, "", withSpecVersion(api("payments-8fk2x", nil, nil), "v2"), "v2.0", versionSourceSpec),
+		Entry("date version", VersionStrategyName, "", withSpecVersion(api("payments-v1", nil, nil), "2024-01-01"), "2024-01-01", versionSourceSpec),
+		Entry("minor version", VersionStrategyName, "", withSpecVersion(api("payments-v1", nil, nil), "v2.1"), "v2.0", versionSourceSpec),
+		Entry("semver", VersionStrategySemver, "", withSpecVersion(api("payments-v1.0.0", nil, nil), "v2"), "v2.0.0", versionSourceSpec),
+		Entry("cluster API", VersionStrategyAnnotation, "", &clusterapimanagement.API{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments-8fk2x"},
+			Spec:       clusterapimanagement.APISpec{ForProvider: clusterapimanagement.APIParameters{Version: ptr.To("2024-01")}},
+		}, "2024-01", versionSourceSpec),
+		Entry("template", VersionStrategyName, "{major}", withSpecVersion(api("payments-v1", nil, nil), "v3"), "3", versionSourceSpec),
+		Entry("name without spec version", VersionStrategyName, "", api("payments-v1", nil, nil), "v1.0", versionSourceName),
+		Entry("label without spec version", VersionStrategyAnnotation, "",
+			api("payments", nil, map[string]string{"swaggerimporter/version": "v4"}), "v4", versionSourceLabel),
+	)
+
+	DescribeTable("should fail to resolve versions the API does not declare",
+		func(strategy, pattern, key string, api *namespacedapimanagement.API) {
+			resolver, err := NewVersionResolver(strategy, pattern, key, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = resolver.Resolve(api)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("name without -v", VersionStrategyName, "", "", api("payments", nil, nil)),
+		Entry("name without a numeric major version", VersionStrategyName, "", "", api("my-vault-api", nil, nil)),
+		Entry("regex without match", VersionStrategyRegex, `^payments-(?P<version>\d+)-api$`, "", api("orders-3-api", nil, nil)),
+		Entry("annotation without annotation", VersionStrategyAnnotation, "", "swaggerimporter/version", api("payments", nil, nil)),
+		Entry("semver without semantic version", VersionStrategySemver, "", "", api("payments-vnext", nil, nil)),
+	)
+
+	DescribeTable("should only take the major version of numeric versions",
+		func(version string, expected int, numeric bool) {
+			major, err := versionMajor(version)
+			if !numeric {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(major).To(Equal(expected))
+		},
+		Entry("major version", "v2", 2, true),
+		Entry("minor version", "v2.1", 2, true),
+		Entry("without prefix", "3.0.1", 3, true),
+		Entry("date", "2024-01-01", 0, false),
+		Entry("month", "2024-01", 0, false),
+		Entry("pre-release", "v2.0-beta", 0, false),
+		Entry("empty", "", 0, false),
+	)
+
+	DescribeTable("should reject invalid configuration",
+		func(strategy, pattern, key string) {
+			_, err := NewVersionResolver(strategy, pattern, key, "")
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown strategy", "date", "", ""),
+		Entry("invalid pattern", VersionStrategyRegex, "(", ""),
+		Entry("pattern without version group", VersionStrategyRegex, `^payments-(\d+)$`, ""),
+		Entry("annotation without key", VersionStrategyAnnotation, "", ""),
+	)
+
+	It("should render the resolved version into the fetch path", func() {
+		ctx := context.Background()
+		app := newApplication("payments", "default")
+		app.api = api("payments", map[string]string{"swaggerimporter/version": "2024-01"}, map[string]string{"application": "payments"})
+
+		var fetched []string
+		resolver, err := NewVersionResolver(VersionStrategyAnnotation, "", "swaggerimporter/version", "")
+		Expect(err).NotTo(HaveOccurred())
+		reconciler := newTestReconciler(func(ctx context.Context, url string) ([]byte, error) {
+			fetched = append(fetched, url)
+			return []byte(`{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`), nil
+		}, app.objects()...)
+		reconciler.Versions = resolver
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched).To(ConsistOf(ContainSubstring("/swagger/2024-01/swagger.json")))
+	})
+
+	It("should keep the baseline fetch path of APIs declaring their major version", func() {
+		ctx := context.Background()
+		app := newApplication("payments", "default")
+		withSpecVersion(app.api, "v1")
+
+		var fetched []string
+		reconciler := newTestReconciler(func(ctx context.Context, url string) ([]byte, error) {
+			fetched = append(fetched, url)
+			return []byte(`{"openapi": "3.0.1", "info": {"title": "Payments", "version": "1.0"}, "paths": {}}`), nil
+		}, app.objects()...)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "payments", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched).To(ConsistOf("http://payments.default.svc.cluster.local:8080/swagger/v1.0/swagger.json"))
+	})
+})